@@ -2,16 +2,22 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"expvar"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+	"github.com/anhdnyopaz/go_victorialog/internal/logger/vlhttp"
 	"github.com/anhdnyopaz/go_victorialog/internal/service"
 	"github.com/gorilla/mux"
 )
@@ -26,6 +32,30 @@ func StartVictoriaLogService() (*logger.VictoriaLogsLogger, func(), error) {
 		Timeout:         5 * time.Second,
 		BufferSize:      500,
 		Async:           true,
+		Format:          getEnv("LOG_FORMAT", logger.FormatJSON),
+		MinLevel:        logger.DEBUG,
+		StreamFields:    []string{"service"},
+		TopLevelFields:  true,
+		BearerToken:     os.Getenv("VICTORIA_LOGS_TOKEN"),
+		Environment:     getEnv("APP_ENV", "development"),
+		ExpvarPrefix:    "victorialogs",
+		FieldPolicies: map[string]logger.FieldPolicy{
+			"production": {
+				"email":        logger.FieldDrop,
+				"request_body": logger.FieldDrop,
+			},
+			"staging": {
+				"email":        logger.FieldHash,
+				"request_body": logger.FieldHash,
+			},
+		},
+	}
+	if path := os.Getenv("LOG_CONFIG"); path != "" {
+		loaded, err := logger.LoadConfig(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load logger config: %w", err)
+		}
+		config = loaded
 	}
 
 	vlLogger, err := logger.NewVictoriaLogsLogger(config)
@@ -50,23 +80,44 @@ func main() {
 		log.Fatal(err)
 	}
 	defer cleanup()
+
 	
 	// Init Services
-	userService := service.NewUserService(vlLogger)
+	userService := service.NewUserService(vlLogger, service.NewInMemoryUserRepository())
 
 	router := mux.NewRouter()
 
+	var ready atomic.Bool
+	ready.Store(true)
+
 	router.HandleFunc("/health", healthHandler(vlLogger)).Methods("GET")
 
+	router.HandleFunc("/live", liveHandler()).Methods("GET")
+
+	router.HandleFunc("/ready", readyHandler(vlLogger, &ready)).Methods("GET")
+
 	router.HandleFunc("/users", createUserHandler(userService, vlLogger)).Methods("POST")
 
+	router.HandleFunc("/users", listUsersHandler(userService)).Methods("GET")
+
 	router.HandleFunc("/users/{id}", getUserHandler(userService, vlLogger)).Methods("GET")
 
-	router.Use(traceMiddleware(vlLogger))
+	router.HandleFunc("/users/{id}", updateUserHandler(userService, vlLogger)).Methods("PUT")
+
+	router.HandleFunc("/users/{id}", deleteUserHandler(userService)).Methods("DELETE")
+
+	router.Use(vlhttp.Middleware(vlLogger, &vlhttp.Config{
+		TraceHeader: "X-Request-ID",
+		SkipPaths:   []string{"/health", "/live", "/ready"},
+		Route:       muxRoute,
+		Sanitize:    true,
+	}))
 	srv := &http.Server{
 		Addr:    ":8080",
 		Handler: router,
 
+		ErrorLog: log.New(vlLogger.Writer(logger.ERROR), "", 0),
+
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -83,12 +134,43 @@ func main() {
 
 	}()
 
+	// Operator endpoints have no authentication, so they get their own listener, on localhost
+	// unless ADMIN_ADDR says otherwise
+	adminRouter := mux.NewRouter()
+	adminRouter.Handle("/log/level", vlLogger.LevelHandler()).Methods("GET", "PUT")
+	// expvar also publishes memstats and the command line, which may carry secrets
+	adminRouter.Handle("/debug/vars", expvar.Handler()).Methods("GET")
+	adminSrv := &http.Server{
+		Addr:    getEnv("ADMIN_ADDR", "127.0.0.1:9090"),
+		Handler: adminRouter,
+
+		ErrorLog: log.New(vlLogger.Writer(logger.ERROR), "", 0),
+
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+	}
+	go func() {
+		if err := adminSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			vlLogger.Error(context.Background(), "Failed to start admin server", map[string]interface{}{
+				"addr":  adminSrv.Addr,
+				"error": err.Error(),
+			})
+		}
+	}()
+
 	//go demoLogs(vlLogger)
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	<-c
-	vlLogger.Info(context.Background(), "Shutting down server", nil)
+	// Stop receiving traffic first, then drain requests and finally the log buffer. /ready fails
+	// for the grace period before the listener closes, so load balancers notice in time.
+	ready.Store(false)
+	grace := readinessGrace()
+	vlLogger.Info(context.Background(), "Shutting down server", map[string]interface{}{
+		"readiness_grace": grace.Milliseconds(),
+	})
+	time.Sleep(grace)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -98,10 +180,17 @@ func main() {
 			"error": err.Error(),
 		})
 	}
+	_ = adminSrv.Shutdown(ctx)
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if err := vlLogger.Flush(flushCtx); err != nil {
+		log.Printf("Error flushing logger: %v", err)
+	}
 
 }
 
-func demoLogs(logger logger.Logger) {
+func demoLogs(l logger.Logger) {
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
@@ -110,29 +199,29 @@ func demoLogs(logger logger.Logger) {
 		select {
 		case <-ticker.C:
 			counter++
-			ctx := context.WithValue(context.Background(), "trace_id", fmt.Sprintf("demo_trace_%d", counter))
+			ctx := logger.ContextWithTraceID(context.Background(), fmt.Sprintf("demo_trace_%d", counter))
 
 			// Different log levels
-			logger.Debug(ctx, "Debug message for testing", map[string]interface{}{
+			l.Debug(ctx, "Debug message for testing", map[string]interface{}{
 				"counter": counter,
 				"type":    "debug_demo",
 			})
 
-			logger.Info(ctx, "Processing demo data", map[string]interface{}{
+			l.Info(ctx, "Processing demo data", map[string]interface{}{
 				"counter":    counter,
 				"batch_size": 100,
 				"type":       "info_demo",
 			})
 
 			if counter%5 == 0 {
-				logger.Warn(ctx, "This is a warning message", map[string]interface{}{
+				l.Warn(ctx, "This is a warning message", map[string]interface{}{
 					"counter": counter,
 					"type":    "warn_demo",
 				})
 			}
 
 			if counter%10 == 0 {
-				logger.Error(ctx, "Simulated error occurred", map[string]interface{}{
+				l.Error(ctx, "Simulated error occurred", map[string]interface{}{
 					"counter":    counter,
 					"error_code": "DEMO_ERROR",
 					"type":       "error_demo",
@@ -148,23 +237,40 @@ func demoLogs(logger logger.Logger) {
 
 }
 
-func traceMiddleware(logger logger.Logger) mux.MiddlewareFunc {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			traceId := fmt.Sprintf("trace_%d", time.Now().UnixNano())
-			ctx := context.WithValue(r.Context(), "trace_id", traceId)
+// muxRoute returns the path template of the gorilla/mux route the request matched
+func muxRoute(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		tpl, _ := route.GetPathTemplate()
+		return tpl
+	}
+	return ""
+}
 
-			logger.Info(ctx, "Request received", map[string]interface{}{
-				"method":     r.Method,
-				"path":       r.URL.Path,
-				"trace_id":   traceId,
-				"user_agent": r.UserAgent(),
-				"remote_ip":  r.RemoteAddr,
-			})
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
 
-			next.ServeHTTP(w, r.WithContext(ctx))
-		})
-	}
+type userRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type errorBody struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type listUsersResponse struct {
+	Users    []service.User `json:"users"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+	Total    int            `json:"total"`
 }
 
 func getUserHandler(userService *service.UserService, vlLogger *logger.VictoriaLogsLogger) http.HandlerFunc {
@@ -173,40 +279,138 @@ func getUserHandler(userService *service.UserService, vlLogger *logger.VictoriaL
 		userId := vars["id"]
 
 		user, err := userService.GetUser(r.Context(), userId)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+		if errors.Is(err, service.ErrUserNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "user not found", nil)
 			return
 		}
-		w.Header().Set("Content-Type", "application/json")
-		_, err = w.Write([]byte(fmt.Sprintf(`{"id":"%s", "username":"%s","email":"%s"`,
-			userId, user.Username, user.Email)))
 		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to get user", nil)
 			return
 		}
+		writeJSON(w, http.StatusOK, user)
 	}
 
 }
 
 func createUserHandler(userService *service.UserService, vlLogger *logger.VictoriaLogsLogger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		user := service.User{
-			ID:       fmt.Sprintf("user_%d", time.Now().Unix()),
-			Username: r.URL.Query().Get("username"),
-			Email:    r.URL.Query().Get("email"),
+		user, ok := decodeUser(w, r, vlLogger)
+		if !ok {
+			return
 		}
+		user.ID = fmt.Sprintf("user_%d", time.Now().UnixNano())
+
 		if err := userService.CreateUser(r.Context(), user); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to create user", nil)
+			return
+		}
+		writeJSON(w, http.StatusCreated, user)
+	}
+}
+
+func listUsersHandler(userService *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, err := queryInt(r, "page", 1)
+		if err != nil || page < 1 {
+			writeError(w, http.StatusBadRequest, "invalid_query", "page must be a positive integer", nil)
+			return
+		}
+		pageSize, err := queryInt(r, "page_size", defaultPageSize)
+		if err != nil || pageSize < 1 || pageSize > maxPageSize {
+			writeError(w, http.StatusBadRequest, "invalid_query", fmt.Sprintf("page_size must be between 1 and %d", maxPageSize), nil)
 			return
 		}
-		w.WriteHeader(http.StatusCreated)
-		_, err := w.Write([]byte("User created successfully!"))
+
+		users, total, err := userService.ListUsers(r.Context(), page, pageSize)
 		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to list users", nil)
 			return
 		}
+		writeJSON(w, http.StatusOK, listUsersResponse{Users: users, Page: page, PageSize: pageSize, Total: total})
+	}
+}
 
+func updateUserHandler(userService *service.UserService, vlLogger *logger.VictoriaLogsLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := decodeUser(w, r, vlLogger)
+		if !ok {
+			return
+		}
+		user.ID = mux.Vars(r)["id"]
+
+		err := userService.UpdateUser(r.Context(), user)
+		if errors.Is(err, service.ErrUserNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "user not found", nil)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to update user", nil)
+			return
+		}
+		writeJSON(w, http.StatusOK, user)
+	}
+}
+
+func deleteUserHandler(userService *service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := userService.DeleteUser(r.Context(), mux.Vars(r)["id"])
+		if errors.Is(err, service.ErrUserNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "user not found", nil)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to delete user", nil)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// decodeUser reads and validates a userRequest body, writing the error response itself on failure
+func decodeUser(w http.ResponseWriter, r *http.Request, vlLogger *logger.VictoriaLogsLogger) (service.User, bool) {
+	var req userRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		vlLogger.Warn(r.Context(), "Invalid user request body", map[string]interface{}{
+			"error": logger.Sanitize(err.Error()),
+		})
+		writeError(w, http.StatusBadRequest, "invalid_body", "request body must be a JSON object with username and email", nil)
+		return service.User{}, false
+	}
+
+	user := service.User{
+		Username: strings.TrimSpace(req.Username),
+		Email:    strings.TrimSpace(req.Email),
+	}
+	var validationErr *service.ValidationError
+	if err := user.Validate(); errors.As(err, &validationErr) {
+		writeError(w, http.StatusUnprocessableEntity, "validation_failed", "invalid user", validationErr.Fields)
+		return service.User{}, false
+	}
+	return user, true
+}
+
+func queryInt(r *http.Request, name string, fallback int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(raw)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		return
 	}
 }
 
+func writeError(w http.ResponseWriter, status int, code string, message string, fields map[string]string) {
+	writeJSON(w, status, errorResponse{Error: errorBody{Code: code, Message: message, Fields: fields}})
+}
+
 func healthHandler(logger logger.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logger.Debug(r.Context(), "Health check requested", nil)
@@ -218,6 +422,52 @@ func healthHandler(logger logger.Logger) http.HandlerFunc {
 	}
 }
 
+// liveHandler reports that the process is running; it deliberately checks nothing else
+func liveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte("OK"))
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readyHandler reports whether the instance should receive traffic: it must not be shutting down
+// and the logger must reach VictoriaLogs with buffer room to spare
+func readyHandler(vlLogger *logger.VictoriaLogsLogger, ready *atomic.Bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !ready.Load() {
+			writeError(w, http.StatusServiceUnavailable, "shutting_down", "server is shutting down", nil)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+		health := vlLogger.Health(ctx)
+		if !health.Healthy {
+			vlLogger.Warn(r.Context(), "Readiness check failed", map[string]interface{}{
+				"error":              health.Error,
+				"buffer_utilization": health.BufferUtilization,
+			})
+			writeJSON(w, http.StatusServiceUnavailable, health)
+			return
+		}
+		writeJSON(w, http.StatusOK, health)
+	}
+}
+
+// readinessGrace is how long /ready fails before the server stops accepting connections,
+// SHUTDOWN_GRACE or 5s
+func readinessGrace() time.Duration {
+	grace, err := time.ParseDuration(getEnv("SHUTDOWN_GRACE", "5s"))
+	if err != nil || grace < 0 {
+		log.Printf("Invalid SHUTDOWN_GRACE %q, using 5s", os.Getenv("SHUTDOWN_GRACE"))
+		return 5 * time.Second
+	}
+	return grace
+}
+
 func getEnv(env string, fallback string) string {
 	if value := os.Getenv(env); value != "" {
 		return value
@@ -0,0 +1,35 @@
+package logger
+
+import "context"
+
+const (
+	// StreamKey is the top-level field holding LogEntry.Stream; it is added to _stream_fields for audit batches
+	StreamKey = "stream"
+	// AuditStream is the StreamKey value of entries written with Audit
+	AuditStream = "audit"
+)
+
+// Audit records a compliance event such as a login or a permission change on the audit stream.
+// Audit entries skip the level filter, rate limiting, deduplication and the buffer: they are sent
+// synchronously to Config.AuditURL (VictoriaLogsURL when empty) and Config.AuditTenant (when set),
+// and the error is returned when VictoriaLogs did not accept them after all retries, in which case
+// they go to the fallback sink.
+func (v *VictoriaLogsLogger) Audit(ctx context.Context, action string, fields map[string]interface{}) error {
+	if ctx == nil {
+		ctx = v.ctx
+	}
+	entry := v.createLogEntry(INFO, action, fields)
+	entry.Stream = AuditStream
+	if v.cfg().AuditTenant != (Tenant{}) {
+		entry.Tenant = v.cfg().AuditTenant
+	}
+	if v.cfg().EnableCaller {
+		// Audit calls addCaller directly, one frame less than log
+		addCaller(entry.Fields, v.cfg().CallerSkip-1)
+	}
+	enrichFromContext(ctx, &entry, v.cfg().contextExtractors())
+	// Hooks may enrich or redact audit entries but cannot drop them
+	v.hooks.run(&entry, v.reportError)
+	v.cfg().fieldPolicy().applyEntry(&entry)
+	return v.deliver([]LogEntry{entry}, v.settings.Load().auditRoute, true)
+}
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"errors"
+	"net/http"
+)
+
+// BasicAuth holds HTTP basic auth credentials, e.g. for VictoriaLogs behind vmauth
+type BasicAuth struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// validateAuth rejects configs setting more than one authentication method
+func (c *Config) validateAuth() error {
+	if c.BasicAuth != nil && c.BearerToken != "" {
+		return errors.New("BasicAuth and BearerToken are mutually exclusive")
+	}
+	return nil
+}
+
+// applyHeaders sets Config.Headers and the configured credentials on a request sent to VictoriaLogs.
+// Credentials are applied last so they win over an Authorization entry in Headers.
+func (c *Config) applyHeaders(req *http.Request) {
+	for name, value := range c.Headers {
+		req.Header.Set(name, value)
+	}
+	c.applyAuth(req)
+}
+
+// applyAuth adds the configured credentials to a request sent to VictoriaLogs
+func (c *Config) applyAuth(req *http.Request) {
+	switch {
+	case c.BasicAuth != nil:
+		req.SetBasicAuth(c.BasicAuth.Username, c.BasicAuth.Password)
+	case c.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+c.BearerToken)
+	}
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// Defaults for the retry backoff settings
+const (
+	defaultRetryBackoff    = time.Second
+	defaultRetryMaxBackoff = 30 * time.Second
+	defaultRetryJitter     = 0.5
+)
+
+// Backoff decides how long to wait before retry number attempt (0 for the first retry). The
+// attempt never exceeds RetryPolicy.MaxRetries, even when at-least-once delivery retries longer.
+type Backoff interface {
+	Delay(attempt int) time.Duration
+}
+
+// BackoffFunc adapts a function to Backoff
+type BackoffFunc func(attempt int) time.Duration
+
+func (f BackoffFunc) Delay(attempt int) time.Duration {
+	return f(attempt)
+}
+
+// ExponentialBackoff doubles Initial per attempt up to Max, then subtracts a random part of
+// up to Jitter (0 to 1) of the wait, so instances that failed together don't retry together
+type ExponentialBackoff struct {
+	Initial time.Duration
+	Max     time.Duration
+	Jitter  float64
+}
+
+func (b ExponentialBackoff) Delay(attempt int) time.Duration {
+	d := b.Initial
+	for i := 0; i < attempt && d < b.Max; i++ {
+		d *= 2
+	}
+	d = min(d, b.Max)
+	spread := time.Duration(float64(d) * min(max(b.Jitter, 0), 1))
+	if spread <= 0 {
+		return d
+	}
+	return d - rand.N(spread+1)
+}
+
+// RetryPolicy controls how failed insert requests are retried. Zero fields fall back to the
+// flat Config fields, then to the defaults.
+type RetryPolicy struct {
+	// MaxRetries is the number of attempts per request; zero means Config.MaxRetries
+	MaxRetries int `yaml:"max_retries"`
+	// InitialBackoff is the wait before the first retry (default 1s), doubled per retry up to
+	// MaxBackoff (default 30s)
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+	// Jitter is the fraction of each wait that is randomized (default 0.5, so a random point in
+	// its upper half); negative disables it
+	Jitter float64 `yaml:"jitter"`
+	// MaxElapsed stops retrying once the next wait would exceed it since the first attempt;
+	// zero means MaxRetries alone decides
+	MaxElapsed time.Duration `yaml:"max_elapsed"`
+	// RetryableStatusCodes replaces the default 429 and 5xx; network errors are always retried
+	RetryableStatusCodes []int `yaml:"retryable_status_codes"`
+	// Backoff replaces the exponential backoff built from the fields above
+	Backoff Backoff `yaml:"-"`
+}
+
+// retryPolicy resolves Retry against the deprecated flat fields and the defaults
+func (c *Config) retryPolicy() RetryPolicy {
+	p := c.Retry
+	if p.MaxRetries <= 0 {
+		p.MaxRetries = c.MaxRetries
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = c.RetryBackoff
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultRetryBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = c.RetryMaxBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultRetryMaxBackoff
+	}
+	if p.Jitter == 0 {
+		p.Jitter = defaultRetryJitter
+	}
+	if p.MaxElapsed <= 0 {
+		p.MaxElapsed = c.RetryMaxElapsed
+	}
+	if p.Backoff == nil {
+		p.Backoff = ExponentialBackoff{Initial: p.InitialBackoff, Max: p.MaxBackoff, Jitter: p.Jitter}
+	}
+	return p
+}
+
+// retryable reports whether err is worth another attempt: network errors always are, HTTP
+// errors when their status is in RetryableStatusCodes, or 429 and 5xx by default
+func (p RetryPolicy) retryable(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && len(p.RetryableStatusCodes) > 0 {
+		return slices.Contains(p.RetryableStatusCodes, httpErr.StatusCode)
+	}
+	return retryable(err)
+}
+
+// budgetLeft reports whether waiting another wait at now still fits in MaxElapsed,
+// counted from the first attempt at start
+func (p RetryPolicy) budgetLeft(start, now time.Time, wait time.Duration) bool {
+	if p.MaxElapsed <= 0 {
+		return true
+	}
+	return now.Add(wait).Sub(start) <= p.MaxElapsed
+}
@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for batches sent to the fallback sink without an attempt because
+// the circuit breaker is open
+var ErrCircuitOpen = errors.New("logger: circuit breaker open")
+
+// Circuit breaker states as reported in HealthStatus.Circuit
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// CircuitBreakerConfig stops requests to VictoriaLogs after Threshold consecutive failed
+// attempts, so an outage costs no retry sleeps: batches go straight to the fallback sink.
+// After OpenTimeout one batch is let through as a probe; its success closes the circuit,
+// its failure opens it again. A zero Threshold disables the breaker.
+type CircuitBreakerConfig struct {
+	Threshold   int           `yaml:"threshold"`
+	OpenTimeout time.Duration `yaml:"open_timeout"`
+}
+
+const defaultCircuitOpenTimeout = 30 * time.Second
+
+// circuitBreaker implements CircuitBreakerConfig. A nil breaker always allows requests.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	openFor   time.Duration
+	failures  int
+	state     string
+	openedAt  time.Time
+}
+
+func newCircuitBreaker(c CircuitBreakerConfig) *circuitBreaker {
+	if c.Threshold <= 0 {
+		return nil
+	}
+	openFor := c.OpenTimeout
+	if openFor <= 0 {
+		openFor = defaultCircuitOpenTimeout
+	}
+	return &circuitBreaker{threshold: c.Threshold, openFor: openFor, state: CircuitClosed}
+}
+
+// allow reports whether a request may be made. Once OpenTimeout has passed it lets a single
+// probe through and holds back everything else until the probe is reported.
+func (b *circuitBreaker) allow(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if now.Sub(b.openedAt) < b.openFor {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		return true
+	case CircuitHalfOpen:
+		return false
+	}
+	return true
+}
+
+// success records a request VictoriaLogs answered, even with a permanent rejection
+func (b *circuitBreaker) success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.state = CircuitClosed
+}
+
+// failure records a failed request and reports whether the circuit is now open
+func (b *circuitBreaker) failure(now time.Time) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = CircuitOpen
+		b.openedAt = now
+	}
+	return b.state == CircuitOpen
+}
+
+func (b *circuitBreaker) currentState() string {
+	if b == nil {
+		return ""
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
@@ -0,0 +1,131 @@
+package logger
+
+import "sync/atomic"
+
+// LoggerBuilder assembles a logger step by step: enrichers, filters and samplers run on every
+// entry in the order they were added, before the entry is buffered, and the entry then goes to
+// VictoriaLogs and every sink. Start one with Builder.
+type LoggerBuilder struct {
+	config *Config
+	hooks  []Hook
+	policy FieldPolicy
+}
+
+// Enricher adds or changes fields of an entry
+type Enricher func(entry *LogEntry)
+
+// Filter reports whether an entry should be kept
+type Filter func(entry *LogEntry) bool
+
+// Sampler decides which entries are kept when there are too many to send them all
+type Sampler interface {
+	Sample(entry *LogEntry) bool
+}
+
+// Builder starts from DefaultConfig
+func Builder() *LoggerBuilder {
+	return &LoggerBuilder{config: DefaultConfig()}
+}
+
+// With applies functional options to the config, see New
+func (b *LoggerBuilder) With(opts ...Option) *LoggerBuilder {
+	for _, opt := range opts {
+		opt(b.config)
+	}
+	return b
+}
+
+// WithSink adds a sink receiving every batch
+func (b *LoggerBuilder) WithSink(sink Sink) *LoggerBuilder {
+	b.config.Sinks = append(b.config.Sinks, sink)
+	return b
+}
+
+// WithFallbackSink sets the sink for batches VictoriaLogs did not accept
+func (b *LoggerBuilder) WithFallbackSink(sink Sink) *LoggerBuilder {
+	b.config.FallbackSink = sink
+	return b
+}
+
+// WithEnricher adds a step changing every entry
+func (b *LoggerBuilder) WithEnricher(enrich Enricher) *LoggerBuilder {
+	return b.WithHook(func(entry *LogEntry) error {
+		enrich(entry)
+		return nil
+	})
+}
+
+// WithFilter adds a step dropping entries keep rejects
+func (b *LoggerBuilder) WithFilter(keep Filter) *LoggerBuilder {
+	return b.WithHook(func(entry *LogEntry) error {
+		if !keep(entry) {
+			return ErrDropEntry
+		}
+		return nil
+	})
+}
+
+// WithSampler adds a step dropping entries the sampler does not keep. Like other steps it is
+// fixed once built; Config.Sampling is the per-level sampling Reload can change.
+func (b *LoggerBuilder) WithSampler(sampler Sampler) *LoggerBuilder {
+	return b.WithFilter(sampler.Sample)
+}
+
+// WithHook adds a hook as a step of the pipeline
+func (b *LoggerBuilder) WithHook(hook Hook) *LoggerBuilder {
+	b.hooks = append(b.hooks, hook)
+	return b
+}
+
+// WithRedaction drops or hashes fields in the environment the logger is built for, see
+// FieldPolicy. Fields are redacted after context extractors and hooks have run.
+func (b *LoggerBuilder) WithRedaction(policy FieldPolicy) *LoggerBuilder {
+	if b.policy == nil {
+		b.policy = make(FieldPolicy, len(policy))
+	}
+	for field, action := range policy {
+		b.policy[field] = action
+	}
+	return b
+}
+
+// Build creates the logger. Steps added to the builder run after Config.Hooks.
+func (b *LoggerBuilder) Build() (*VictoriaLogsLogger, error) {
+	config := *b.config
+	config.Hooks = append(append([]Hook(nil), config.Hooks...), b.hooks...)
+	if b.policy != nil {
+		policies := make(map[string]FieldPolicy, len(config.FieldPolicies)+1)
+		for env, p := range config.FieldPolicies {
+			policies[env] = p
+		}
+		merged := make(FieldPolicy, len(policies[config.Environment])+len(b.policy))
+		for field, action := range policies[config.Environment] {
+			merged[field] = action
+		}
+		for field, action := range b.policy {
+			merged[field] = action
+		}
+		policies[config.Environment] = merged
+		config.FieldPolicies = policies
+	}
+	config.fillDefaults()
+	return NewVictoriaLogsLogger(&config)
+}
+
+// everyN keeps the first of every n entries below ERROR
+type everyN struct {
+	n     uint64
+	count atomic.Uint64
+}
+
+// SampleEveryN keeps one in n entries below ERROR; ERROR and above are always kept
+func SampleEveryN(n int) Sampler {
+	return &everyN{n: uint64(max(n, 1))}
+}
+
+func (s *everyN) Sample(entry *LogEntry) bool {
+	if entry.Level >= ERROR {
+		return true
+	}
+	return (s.count.Add(1)-1)%s.n == 0
+}
@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	CallerKey = "caller"
+	FuncKey   = "func"
+)
+
+// callerDepth is the number of frames between runtime.Caller inside addCaller and the code
+// that called a public logging method: addCaller <- log <- Info/Infof/Infow/Log <- caller
+const callerDepth = 3
+
+// addCaller records the call site skip frames above the public logging method in fields,
+// unless an adapter already provided one
+func addCaller(fields map[string]interface{}, skip int) {
+	if _, ok := fields[CallerKey]; ok {
+		return
+	}
+	pc, file, line, ok := runtime.Caller(callerDepth + skip)
+	if !ok {
+		return
+	}
+	fields[CallerKey] = trimPath(file) + ":" + strconv.Itoa(line)
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		fields[FuncKey] = fn.Name()
+	}
+}
+
+// trimPath keeps the last directory and the file name, e.g. "service/user_service.go"
+func trimPath(file string) string {
+	idx := strings.LastIndexByte(file, '/')
+	if idx < 0 {
+		return file
+	}
+	idx = strings.LastIndexByte(file[:idx], '/')
+	if idx < 0 {
+		return file
+	}
+	return file[idx+1:]
+}
+
+// StackKey holds the formatted stack trace of entries at or above Config.StackTraceLevel
+const StackKey = "stack"
+
+// maxStackFrames bounds the frames captured for a stack trace
+const maxStackFrames = 64
+
+// addStack records the goroutine stack starting at the caller of the public logging method
+func addStack(fields map[string]interface{}, skip int) {
+	if _, ok := fields[StackKey]; ok {
+		return
+	}
+	pcs := make([]uintptr, maxStackFrames)
+	// +1 because runtime.Callers counts itself, unlike runtime.Caller
+	n := runtime.Callers(callerDepth+skip+1, pcs)
+	if n == 0 {
+		return
+	}
+
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+		b.WriteByte('\n')
+	}
+	fields[StackKey] = b.String()
+}
@@ -0,0 +1,194 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CaptureLogger is a synchronous Logger that keeps entries at or above a minimum level in memory.
+// It runs no goroutines and makes no network calls, which makes it suitable for unit tests.
+type CaptureLogger struct {
+	mu       sync.Mutex
+	minLevel LogLevel
+	entries  []LogEntry
+}
+
+// NewCaptureLogger returns a CaptureLogger that keeps entries at minLevel and above
+func NewCaptureLogger(minLevel LogLevel) *CaptureLogger {
+	return &CaptureLogger{minLevel: minLevel}
+}
+
+func (c *CaptureLogger) Debug(ctx context.Context, msg string, fields map[string]interface{}) {
+	c.capture(ctx, DEBUG, msg, fields)
+}
+
+func (c *CaptureLogger) Info(ctx context.Context, msg string, fields map[string]interface{}) {
+	c.capture(ctx, INFO, msg, fields)
+}
+
+func (c *CaptureLogger) Warn(ctx context.Context, msg string, fields map[string]interface{}) {
+	c.capture(ctx, WARN, msg, fields)
+}
+
+func (c *CaptureLogger) Error(ctx context.Context, msg string, fields map[string]interface{}) {
+	c.capture(ctx, ERROR, msg, fields)
+}
+
+func (c *CaptureLogger) Fatal(ctx context.Context, msg string, fields map[string]interface{}) {
+	c.capture(ctx, FATAL, msg, fields)
+}
+
+// Panic captures the entry and then panics with msg, like the real logger
+func (c *CaptureLogger) Panic(ctx context.Context, msg string, fields map[string]interface{}) {
+	c.capture(ctx, PANIC, msg, fields)
+	panic(msg)
+}
+
+func (c *CaptureLogger) Debugf(ctx context.Context, format string, args ...interface{}) {
+	msg, fields := formatMessage(format, args)
+	c.capture(ctx, DEBUG, msg, fields)
+}
+
+func (c *CaptureLogger) Infof(ctx context.Context, format string, args ...interface{}) {
+	msg, fields := formatMessage(format, args)
+	c.capture(ctx, INFO, msg, fields)
+}
+
+func (c *CaptureLogger) Warnf(ctx context.Context, format string, args ...interface{}) {
+	msg, fields := formatMessage(format, args)
+	c.capture(ctx, WARN, msg, fields)
+}
+
+func (c *CaptureLogger) Errorf(ctx context.Context, format string, args ...interface{}) {
+	msg, fields := formatMessage(format, args)
+	c.capture(ctx, ERROR, msg, fields)
+}
+
+func (c *CaptureLogger) Fatalf(ctx context.Context, format string, args ...interface{}) {
+	msg, fields := formatMessage(format, args)
+	c.capture(ctx, FATAL, msg, fields)
+}
+
+func (c *CaptureLogger) Panicf(ctx context.Context, format string, args ...interface{}) {
+	msg, fields := formatMessage(format, args)
+	c.capture(ctx, PANIC, msg, fields)
+	panic(msg)
+}
+
+func (c *CaptureLogger) Debugw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	c.capture(ctx, DEBUG, msg, fieldsFromKeysAndValues(keysAndValues))
+}
+
+func (c *CaptureLogger) Infow(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	c.capture(ctx, INFO, msg, fieldsFromKeysAndValues(keysAndValues))
+}
+
+func (c *CaptureLogger) Warnw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	c.capture(ctx, WARN, msg, fieldsFromKeysAndValues(keysAndValues))
+}
+
+func (c *CaptureLogger) Errorw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	c.capture(ctx, ERROR, msg, fieldsFromKeysAndValues(keysAndValues))
+}
+
+func (c *CaptureLogger) Fatalw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	c.capture(ctx, FATAL, msg, fieldsFromKeysAndValues(keysAndValues))
+}
+
+func (c *CaptureLogger) Panicw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	c.capture(ctx, PANIC, msg, fieldsFromKeysAndValues(keysAndValues))
+	panic(msg)
+}
+
+func (c *CaptureLogger) Enabled(level LogLevel) bool {
+	return level >= c.minLevel
+}
+
+// Audit captures the entry at INFO with Stream set to AuditStream, whatever the minimum level
+func (c *CaptureLogger) Audit(ctx context.Context, action string, fields map[string]interface{}) error {
+	entry := c.newEntry(ctx, INFO, action, fields)
+	entry.Stream = AuditStream
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, entry)
+	return nil
+}
+
+func (c *CaptureLogger) BatchLog(entries []LogEntry) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, entry := range entries {
+		if entry.Level >= c.minLevel {
+			c.entries = append(c.entries, entry)
+		}
+	}
+	return len(entries), nil
+}
+
+func (c *CaptureLogger) BatchLogContext(_ context.Context, entries []LogEntry) (int, error) {
+	return c.BatchLog(entries)
+}
+
+func (c *CaptureLogger) Flush(ctx context.Context) error {
+	return nil
+}
+
+func (c *CaptureLogger) Close() error {
+	return nil
+}
+
+// Entries returns a copy of the captured entries in logging order
+func (c *CaptureLogger) Entries() []LogEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]LogEntry, len(c.entries))
+	copy(out, c.entries)
+	return out
+}
+
+// Count returns how many entries were captured at the given level
+func (c *CaptureLogger) Count(level LogLevel) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, entry := range c.entries {
+		if entry.Level == level {
+			n++
+		}
+	}
+	return n
+}
+
+// Reset discards all captured entries
+func (c *CaptureLogger) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+}
+
+func (c *CaptureLogger) capture(ctx context.Context, level LogLevel, msg string, fields map[string]interface{}) {
+	if !c.Enabled(level) {
+		return
+	}
+	entry := c.newEntry(ctx, level, msg, fields)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, entry)
+}
+
+func (c *CaptureLogger) newEntry(ctx context.Context, level LogLevel, msg string, fields map[string]interface{}) LogEntry {
+	entry := LogEntry{
+		Level:     level,
+		Message:   msg,
+		Timestamp: time.Now().UnixNano(),
+		Fields:    make(map[string]interface{}, len(fields)),
+	}
+	for k, val := range fields {
+		entry.Fields[k] = val
+	}
+	resolveLazy(entry.Fields)
+	enrichFromContext(ctx, &entry, DefaultContextExtractors())
+	return entry
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression algorithms for Config.Compression
+const (
+	CompressionNone = ""
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// Encoders are pooled so compressing a batch does not allocate a new one each time
+var (
+	gzipWriters = sync.Pool{
+		New: func() interface{} { return gzip.NewWriter(nil) },
+	}
+	zstdEncoders = sync.Pool{
+		New: func() interface{} {
+			enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+			return enc
+		},
+	}
+)
+
+// validateCompression reports an unknown Config.Compression value
+func validateCompression(algo string) error {
+	switch algo {
+	case CompressionNone, CompressionGzip, CompressionZstd:
+		return nil
+	}
+	return fmt.Errorf("unknown compression %q", algo)
+}
+
+// compress returns data encoded with algo and the matching Content-Encoding header value,
+// or data itself and "" when compression is disabled
+func compress(algo string, data []byte) ([]byte, string, error) {
+	switch algo {
+	case CompressionGzip:
+		var buf bytes.Buffer
+		gz := gzipWriters.Get().(*gzip.Writer)
+		defer gzipWriters.Put(gz)
+		gz.Reset(&buf)
+		if _, err := gz.Write(data); err != nil {
+			return nil, "", err
+		}
+		if err := gz.Close(); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "gzip", nil
+	case CompressionZstd:
+		enc := zstdEncoders.Get().(*zstd.Encoder)
+		defer zstdEncoders.Put(enc)
+		return enc.EncodeAll(data, make([]byte, 0, len(data)/2)), "zstd", nil
+	}
+	return data, "", nil
+}
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// Output formats for Config.Format
+const (
+	// FormatJSON sends entries to VictoriaLogs as JSON lines
+	FormatJSON = "json"
+	// FormatConsole prints human-readable entries to stdout instead of sending them anywhere
+	FormatConsole = "console"
+	// FormatStdout writes the VictoriaLogs JSON lines to stdout instead of sending them, for
+	// setups where a node agent (vector, fluent-bit) ships container output
+	FormatStdout = "stdout"
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorGray   = "\x1b[90m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[34m"
+	colorPurple = "\x1b[35m"
+)
+
+// ConsoleEncoder renders entries as single human-readable lines:
+//
+//	06:30:00.000 INFO  demo-api  Create new User  trace_id=abc action=create_user_success
+type ConsoleEncoder struct {
+	Color bool
+}
+
+func (e ConsoleEncoder) Encode(buf *bytes.Buffer, entry LogEntry) error {
+	buf.WriteString(e.paint(colorGray, time.Unix(0, entry.Timestamp).Format("15:04:05.000")))
+	buf.WriteByte(' ')
+	buf.WriteString(e.paint(levelColor(entry.Level), fmt.Sprintf("%-5s", entry.Level)))
+	if entry.Service != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(e.paint(colorGray, entry.Service))
+	}
+	buf.WriteString("  ")
+	buf.WriteString(entry.Message)
+
+	fields := make(map[string]interface{}, len(entry.Fields)+2)
+	flattenInto(fields, "", entry.Fields)
+	if entry.TraceID != "" {
+		fields["trace_id"] = entry.TraceID
+	}
+	if entry.UserID != "" {
+		fields["user_id"] = entry.UserID
+	}
+	if entry.Stream != "" {
+		fields[StreamKey] = entry.Stream
+	}
+	stack, hasStack := fields[StackKey]
+	delete(fields, StackKey)
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		if i == 0 {
+			buf.WriteString(" ")
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(e.paint(colorBlue, k))
+		buf.WriteByte('=')
+		fmt.Fprintf(buf, "%v", fields[k])
+	}
+	buf.WriteByte('\n')
+	if hasStack {
+		fmt.Fprintf(buf, "%v\n", stack)
+	}
+	return nil
+}
+
+func (e ConsoleEncoder) paint(color, s string) string {
+	if !e.Color {
+		return s
+	}
+	return color + s + colorReset
+}
+
+func levelColor(level LogLevel) string {
+	switch level {
+	case DEBUG:
+		return colorGray
+	case INFO:
+		return colorGreen
+	case WARN:
+		return colorYellow
+	case ERROR:
+		return colorRed
+	default:
+		return colorPurple
+	}
+}
+
+// ConsoleSink prints entries in a colored, human-readable format for local development
+type ConsoleSink struct {
+	*WriterSink
+}
+
+// NewConsoleSink writes to w, with colors unless the NO_COLOR environment variable is set
+func NewConsoleSink(w io.Writer) *ConsoleSink {
+	_, noColor := os.LookupEnv("NO_COLOR")
+	return &ConsoleSink{WriterSink: NewWriterSink(w, ConsoleEncoder{Color: !noColor})}
+}
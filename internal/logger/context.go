@@ -0,0 +1,46 @@
+package logger
+
+import "context"
+
+// contextKey is unexported so no other package can collide with the logger's keys
+type contextKey int
+
+const (
+	traceIDContextKey contextKey = iota
+	userIDContextKey
+)
+
+// ContextWithTraceID returns a copy of ctx carrying the trace ID picked up by the logger
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDContextKey, traceID)
+}
+
+// TraceIDFromContext returns the trace ID set with ContextWithTraceID. For compatibility it
+// falls back to a string stored under the plain "trace_id" key.
+func TraceIDFromContext(ctx context.Context) string {
+	return stringFromContext(ctx, traceIDContextKey, "trace_id")
+}
+
+// ContextWithUserID returns a copy of ctx carrying the user ID picked up by the logger
+func ContextWithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDContextKey, userID)
+}
+
+// UserIDFromContext returns the user ID set with ContextWithUserID, falling back to the
+// plain "user_id" key
+func UserIDFromContext(ctx context.Context) string {
+	return stringFromContext(ctx, userIDContextKey, "user_id")
+}
+
+func stringFromContext(ctx context.Context, key contextKey, legacyKey string) string {
+	if ctx == nil {
+		return ""
+	}
+	if s, ok := ctx.Value(key).(string); ok {
+		return s
+	}
+	if s, ok := ctx.Value(legacyKey).(string); ok {
+		return s
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// RepeatCountKey holds how many identical entries were collapsed into a dedup summary
+const RepeatCountKey = "repeat_count"
+
+// deduplicator collapses identical entries seen within a window. The first occurrence is
+// passed through; duplicates are counted and reported in one summary entry once the window ends.
+type deduplicator struct {
+	mu      sync.Mutex
+	window  time.Duration
+	pending map[uint64]*dedupState
+	// nextSweep is when observe next clears expired windows, which keeps pending bounded
+	// without the async worker
+	nextSweep time.Time
+}
+
+type dedupState struct {
+	entry   LogEntry
+	count   int64
+	expires time.Time
+}
+
+func newDeduplicator(window time.Duration) *deduplicator {
+	if window <= 0 {
+		return nil
+	}
+	return &deduplicator{window: window, pending: make(map[uint64]*dedupState)}
+}
+
+// observe reports whether entry should be logged and returns the summaries of expired
+// windows to emit first. Expired windows are cleared at most once per window.
+func (d *deduplicator) observe(entry LogEntry, now time.Time) (bool, []LogEntry) {
+	if entry.Level >= PANIC {
+		return true, nil
+	}
+	key := entryHash(entry)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var summaries []LogEntry
+	if !now.Before(d.nextSweep) {
+		summaries = d.sweep(now)
+		d.nextSweep = now.Add(d.window)
+	}
+	state, ok := d.pending[key]
+	if ok && now.Before(state.expires) {
+		state.count++
+		return false, summaries
+	}
+
+	if ok && state.count > 0 {
+		summaries = append(summaries, *state.summary(now))
+	}
+	d.pending[key] = &dedupState{entry: entry, expires: now.Add(d.window)}
+	return true, summaries
+}
+
+// expired removes finished windows and returns summaries for those that saw duplicates
+func (d *deduplicator) expired(now time.Time) []LogEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.sweep(now)
+}
+
+func (d *deduplicator) sweep(now time.Time) []LogEntry {
+	var summaries []LogEntry
+	for key, state := range d.pending {
+		if now.Before(state.expires) {
+			continue
+		}
+		if state.count > 0 {
+			summaries = append(summaries, *state.summary(now))
+		}
+		delete(d.pending, key)
+	}
+	return summaries
+}
+
+func (s *dedupState) summary(now time.Time) *LogEntry {
+	entry := s.entry
+	fields := make(map[string]interface{}, len(entry.Fields)+1)
+	for k, val := range entry.Fields {
+		fields[k] = val
+	}
+	fields[RepeatCountKey] = s.count
+	entry.Fields = fields
+	entry.Timestamp = now.UnixNano()
+	return &entry
+}
+
+// entryHash identifies an entry by everything except its timestamp
+func entryHash(entry LogEntry) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00", entry.Level, entry.Service, entry.TraceID, entry.UserID, entry.Message,
+		entry.Tenant.AccountID, entry.Tenant.ProjectID)
+	if data, err := json.Marshal(entry.Fields); err == nil {
+		h.Write(data)
+	} else {
+		fmt.Fprint(h, entry.Fields)
+	}
+	return h.Sum64()
+}
+
+// flushDedup emits summaries for windows that ended; with force every pending window is closed
+func (v *VictoriaLogsLogger) flushDedup(force bool) {
+	if v.dedup == nil {
+		return
+	}
+	now := v.cfg().clock().Now()
+	if force {
+		now = now.Add(v.dedup.window)
+	}
+	for _, summary := range v.dedup.expired(now) {
+		v.enqueue(summary)
+	}
+}
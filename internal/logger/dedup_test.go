@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDeduplicatorSweepsExpiredWindows(t *testing.T) {
+	d := newDeduplicator(time.Second)
+	now := time.Date(2024, 10, 21, 6, 30, 0, 0, time.UTC)
+	repeated := LogEntry{Level: WARN, Message: "Cache miss"}
+	d.observe(repeated, now)
+	if keep, _ := d.observe(repeated, now.Add(time.Millisecond)); keep {
+		t.Fatal("duplicate within the window was kept")
+	}
+	for i := 0; i < 100; i++ {
+		d.observe(LogEntry{Level: INFO, Message: fmt.Sprintf("request %d", i)}, now)
+	}
+
+	// Only a distinct entry is logged after the window: the repeated one must still be summarized
+	keep, summaries := d.observe(LogEntry{Level: INFO, Message: "later"}, now.Add(2*time.Second))
+	if !keep {
+		t.Error("distinct entry was dropped")
+	}
+	if len(summaries) != 1 || summaries[0].Message != "Cache miss" || summaries[0].Fields[RepeatCountKey] != int64(1) {
+		t.Errorf("summaries = %+v, want one for Cache miss with repeat_count 1", summaries)
+	}
+	if len(d.pending) != 1 {
+		t.Errorf("%d windows pending, want only the new one", len(d.pending))
+	}
+}
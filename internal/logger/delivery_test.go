@@ -0,0 +1,140 @@
+package logger_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+	"github.com/anhdnyopaz/go_victorialog/internal/logger/logtest"
+)
+
+// advanceUntil moves clock forward in steps until done reports true
+func advanceUntil(t *testing.T, clock *logtest.FakeClock, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met after advancing the clock for 5s")
+		}
+		clock.Advance(time.Minute)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func atLeastOnce(clock *logtest.FakeClock) func(*logger.Config) {
+	return func(c *logger.Config) {
+		c.Clock = clock
+		c.Delivery = logger.DeliveryAtLeastOnce
+		c.MaxRetries = 2
+	}
+}
+
+func TestAtLeastOnceAcksAfterSuccess(t *testing.T) {
+	fake := logtest.NewFakeVictoriaLogs(t)
+	fake.FailNext(5, http.StatusServiceUnavailable)
+	clock := logtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	dir := t.TempDir()
+	l := newAsyncLogger(t, fake, atLeastOnce(clock), func(c *logger.Config) { c.Queue.Dir = dir })
+
+	l.Info(context.Background(), "Kept until accepted", nil)
+	advanceUntil(t, clock, func() bool {
+		// Delivery happens before the ack, so read the queue first
+		queued := l.Stats().Queued
+		delivered := len(fake.Entries())
+		if queued == 0 && delivered == 0 {
+			t.Fatal("entry acknowledged before VictoriaLogs accepted it")
+		}
+		return delivered == 1
+	})
+	if n := fake.Requests(); n != 6 {
+		t.Errorf("%d requests, want 5 failures and 1 success", n)
+	}
+	advanceUntil(t, clock, func() bool { return l.Stats().Queued == 0 })
+}
+
+func TestAtLeastOnceAuditIsBounded(t *testing.T) {
+	fake := logtest.NewFakeVictoriaLogs(t)
+	fake.RespondWith(http.StatusServiceUnavailable)
+	clock := logtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	l := newAsyncLogger(t, fake, atLeastOnce(clock))
+
+	done := make(chan error, 1)
+	go func() { done <- l.Audit(context.Background(), "user.deleted", nil) }()
+	var err error
+	advanceUntil(t, clock, func() bool {
+		select {
+		case err = <-done:
+			return true
+		default:
+			return false
+		}
+	})
+	if err == nil {
+		t.Error("Audit succeeded while VictoriaLogs was down")
+	}
+	if n := fake.Requests(); n != 2 {
+		t.Errorf("%d requests, want MaxRetries (2)", n)
+	}
+}
+
+func TestAtLeastOnceRequiresAsync(t *testing.T) {
+	config := logtest.SyncConfig("http://localhost:9428/insert/jsonline")
+	config.Delivery = logger.DeliveryAtLeastOnce
+	if _, err := logger.NewVictoriaLogsLogger(config); err == nil {
+		t.Error("NewVictoriaLogsLogger accepted at-least-once delivery without Async")
+	}
+}
+
+func TestAtLeastOnceCloseWhileDown(t *testing.T) {
+	fake := logtest.NewFakeVictoriaLogs(t)
+	fake.RespondWith(http.StatusServiceUnavailable)
+	clock := logtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	dir := t.TempDir()
+	l := newAsyncLogger(t, fake, atLeastOnce(clock), func(c *logger.Config) {
+		c.Queue.Dir = dir
+		c.DrainTimeout = 50 * time.Millisecond
+	})
+	l.Info(context.Background(), "Survives the restart", nil)
+	advanceUntil(t, clock, func() bool { return fake.Requests() > 0 })
+
+	closed := make(chan error, 1)
+	go func() { closed <- l.Close() }()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return while VictoriaLogs was down")
+	}
+
+	fake.RespondWith(http.StatusNoContent)
+	restarted := newAsyncLogger(t, fake, func(c *logger.Config) { c.Queue.Dir = dir })
+	if err := restarted.Flush(context.Background()); err != nil {
+		t.Fatalf("flush after restart: %v", err)
+	}
+	if n := len(fake.Entries()); n != 1 {
+		t.Errorf("%d entries delivered after restart, want 1", n)
+	}
+}
+
+func TestAtLeastOnceBackoffAttemptCapped(t *testing.T) {
+	fake := logtest.NewFakeVictoriaLogs(t)
+	fake.FailNext(10, http.StatusServiceUnavailable)
+	clock := logtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	attempts := make(chan int, 20)
+	l := newAsyncLogger(t, fake, atLeastOnce(clock), func(c *logger.Config) {
+		c.Retry.Backoff = logger.BackoffFunc(func(attempt int) time.Duration {
+			attempts <- attempt
+			return time.Second
+		})
+	})
+
+	l.Info(context.Background(), "Retried past MaxRetries", nil)
+	advanceUntil(t, clock, func() bool { return len(fake.Entries()) == 1 })
+	close(attempts)
+	for attempt := range attempts {
+		if attempt > 2 {
+			t.Errorf("Backoff.Delay(%d), want attempts up to MaxRetries (2)", attempt)
+		}
+	}
+}
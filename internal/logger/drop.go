@@ -0,0 +1,25 @@
+package logger
+
+import "errors"
+
+// Reasons passed to Config.OnDrop besides delivery and disk queue errors
+var (
+	// ErrBufferFull is reported for entries that did not fit in the in-memory buffer
+	ErrBufferFull = errors.New("logger: buffer full")
+	// ErrEntryTooLarge is reported for entries whose encoded size exceeds MaxBatchBytes
+	ErrEntryTooLarge = errors.New("logger: entry larger than MaxBatchBytes")
+	// ErrClosed is reported for entries logged after Close or left buffered when it timed out
+	ErrClosed = errors.New("logger: closed")
+)
+
+// drop counts entries that will not reach VictoriaLogs and reports them to Config.OnDrop
+func (v *VictoriaLogsLogger) drop(entries []LogEntry, reason error) {
+	if len(entries) == 0 {
+		return
+	}
+	v.stats.dropped(len(entries), reason)
+	if v.cfg().OnDrop == nil {
+		return
+	}
+	v.cfg().OnDrop(entries, reason)
+}
@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ElasticsearchSink sends entries with the Elasticsearch _bulk protocol, e.g. to VictoriaLogs'
+// /insert/elasticsearch/_bulk endpoint or to any Elastic-compatible pipeline. Documents use the
+// "message" and "@timestamp" keys, and the URL gets matching _msg_field and _time_field parameters.
+// Like any sink it can be added to Config.Sinks or replace VictoriaLogs as Config.Output.
+type ElasticsearchSink struct {
+	// Client sends the bulk requests; NewElasticsearchSink sets a client with a 30s timeout
+	Client *http.Client
+	// Headers are added to every request, e.g. Authorization
+	Headers map[string]string
+
+	mu      sync.Mutex
+	url     string
+	action  []byte
+	encoder Encoder
+	buf     bytes.Buffer
+}
+
+// NewElasticsearchSink returns a sink posting to bulkURL, creating documents in index
+// (which may be empty for endpoints that ignore it, such as VictoriaLogs)
+func NewElasticsearchSink(bulkURL, index string) (*ElasticsearchSink, error) {
+	u, err := url.Parse(bulkURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bulk URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("_msg_field", "message")
+	q.Set("_time_field", "@timestamp")
+	u.RawQuery = q.Encode()
+
+	action := map[string]map[string]string{"create": {}}
+	if index != "" {
+		action["create"]["_index"] = index
+	}
+	actionLine, err := json.Marshal(action)
+	if err != nil {
+		return nil, err
+	}
+	return &ElasticsearchSink{
+		Client:  &http.Client{Timeout: 30 * time.Second},
+		url:     u.String(),
+		action:  append(actionLine, '\n'),
+		encoder: JSONLinesEncoder{TopLevel: true, MsgField: "message", TimeField: "@timestamp"},
+	}, nil
+}
+
+func (s *ElasticsearchSink) Write(ctx context.Context, entries []LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.Reset()
+	for _, entry := range entries {
+		s.buf.Write(s.action)
+		if err := s.encoder.Encode(&s.buf, entry); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(s.buf.Bytes()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	for name, value := range s.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("bulk request returned status code %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return bulkError(body)
+}
+
+// bulkError reports items rejected in a 200 OK bulk response
+func bulkError(body []byte) error {
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if len(body) == 0 || json.Unmarshal(body, &result) != nil || !result.Errors {
+		return nil
+	}
+	failed := 0
+	var first string
+	for _, item := range result.Items {
+		for _, status := range item {
+			if status.Error == nil {
+				continue
+			}
+			failed++
+			if first == "" {
+				first = status.Error.Type + ": " + status.Error.Reason
+			}
+		}
+	}
+	return fmt.Errorf("bulk request rejected %d of %d documents: %s", failed, len(result.Items), first)
+}
+
+// Close is a no-op: requests are synchronous
+func (s *ElasticsearchSink) Close() error {
+	return nil
+}
@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// Encoder writes a single entry, including its trailing newline, in the wire format sent to VictoriaLogs
+type Encoder interface {
+	Encode(buf *bytes.Buffer, entry LogEntry) error
+}
+
+// JSONLinesEncoder encodes entries as VictoriaLogs jsonline records. Field values are normalized
+// first: errors, times, durations (as milliseconds), TextMarshalers and Stringers become scalars.
+type JSONLinesEncoder struct {
+	// Flatten turns nested maps and structs in Fields into dotted keys
+	Flatten bool
+	// TopLevel writes Fields as top-level keys instead of under "fields". A field named like a
+	// built-in key (see reservedKeys) is written as "fields.<name>" so it cannot overwrite it.
+	TopLevel bool
+	// MsgField and TimeField replace the "_msg" and "_time" keys when set
+	MsgField  string
+	TimeField string
+}
+
+// reservedKeys are the top-level keys written by the encoder itself
+var reservedKeys = map[string]bool{
+	"_msg": true, "_time": true, "_stream": true, "level": true,
+	"service": true, TraceIDKey: true, UserIDKey: true, StreamKey: true,
+}
+
+// NewEncoder returns the encoder matching the config
+func NewEncoder(config *Config) Encoder {
+	return JSONLinesEncoder{
+		Flatten:   config.FlattenFields,
+		TopLevel:  config.TopLevelFields,
+		MsgField:  config.MsgField,
+		TimeField: config.TimeField,
+	}
+}
+
+func (e JSONLinesEncoder) Encode(buf *bytes.Buffer, entry LogEntry) error {
+	fields := normalizeFields(entry.Fields)
+	if e.Flatten && fields != nil {
+		flat := make(map[string]interface{}, len(fields))
+		flattenInto(flat, "", fields)
+		fields = flat
+	}
+	vlEntry := VictoriaLogsEntry{
+		Msg:       entry.Message,
+		Time:      time.Unix(0, entry.Timestamp).UTC(),
+		Level:     entry.Level.String(),
+		Service:   entry.Service,
+		TraceId:   entry.TraceID,
+		UserId:    entry.UserID,
+		LogStream: entry.Stream,
+	}
+	if !e.TopLevel {
+		vlEntry.Fields = fields
+	}
+
+	data, err := json.Marshal(vlEntry)
+	if err != nil {
+		return err
+	}
+	if data, err = e.renameBuiltin(data, vlEntry.Msg); err != nil {
+		return err
+	}
+	if e.TopLevel && len(fields) > 0 {
+		return e.writeTopLevel(buf, data, fields)
+	}
+	buf.Write(data)
+	buf.WriteByte('\n')
+	return nil
+}
+
+// renameBuiltin swaps "_msg" and "_time", always the first two keys of an encoded
+// VictoriaLogsEntry, for MsgField and TimeField
+func (e JSONLinesEncoder) renameBuiltin(data []byte, msg string) ([]byte, error) {
+	if e.MsgField == "" && e.TimeField == "" {
+		return data, nil
+	}
+	msgJSON, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	timeValue := data[len(`{"_msg":`)+len(msgJSON)+len(`,"_time":`):]
+
+	out := make([]byte, 0, len(data)+len(e.MsgField)+len(e.TimeField))
+	out = append(out, '{')
+	out = appendKey(out, e.msgField())
+	out = append(out, msgJSON...)
+	out = append(out, ',')
+	out = appendKey(out, e.timeField())
+	return append(out, timeValue...), nil
+}
+
+func appendKey(dst []byte, key string) []byte {
+	encoded, _ := json.Marshal(key)
+	dst = append(dst, encoded...)
+	return append(dst, ':')
+}
+
+func (e JSONLinesEncoder) msgField() string {
+	if e.MsgField != "" {
+		return e.MsgField
+	}
+	return "_msg"
+}
+
+func (e JSONLinesEncoder) timeField() string {
+	if e.TimeField != "" {
+		return e.TimeField
+	}
+	return "_time"
+}
+
+// reserved reports whether a custom field would collide with a key written by the encoder
+func (e JSONLinesEncoder) reserved(key string) bool {
+	return reservedKeys[key] || key == e.MsgField || key == e.TimeField
+}
+
+// writeTopLevel writes the encoded built-in keys followed by fields in the same JSON object
+func (e JSONLinesEncoder) writeTopLevel(buf *bytes.Buffer, builtin []byte, fields map[string]interface{}) error {
+	custom := make(map[string]interface{}, len(fields))
+	for k, val := range fields {
+		if e.reserved(k) {
+			k = "fields." + k
+		}
+		custom[k] = val
+	}
+	data, err := json.Marshal(custom)
+	if err != nil {
+		return err
+	}
+	// builtin always holds at least _msg and _time, so join "{...}" and "{...}" as "{...,...}"
+	buf.Write(builtin[:len(builtin)-1])
+	buf.WriteByte(',')
+	buf.Write(data[1:])
+	buf.WriteByte('\n')
+	return nil
+}
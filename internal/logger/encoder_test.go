@@ -0,0 +1,25 @@
+package logger_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+	"github.com/anhdnyopaz/go_victorialog/internal/logger/logtest"
+)
+
+// TestEncoderGolden pins the wire format; run with UPDATE_GOLDEN=1 after an intended change
+func TestEncoderGolden(t *testing.T) {
+	entries := logtest.SampleEntries(logtest.NewFixedClock())
+	for _, tc := range []struct {
+		name string
+		enc  logger.Encoder
+	}{
+		{"default", logger.JSONLinesEncoder{}},
+		{"top_level_fields", logger.JSONLinesEncoder{TopLevel: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			logtest.AssertGolden(t, filepath.Join("testdata", tc.name+".ndjson"), tc.enc, entries)
+		})
+	}
+}
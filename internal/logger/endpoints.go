@@ -0,0 +1,168 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Endpoint selection policies for Config.EndpointPolicy
+const (
+	// EndpointFailover sends to the first healthy endpoint in list order
+	EndpointFailover = "failover"
+	// EndpointRoundRobin spreads batches over all healthy endpoints
+	EndpointRoundRobin = "round_robin"
+)
+
+// defaultEndpointCooldown applies when EndpointCooldown is not set
+const defaultEndpointCooldown = 30 * time.Second
+
+// EndpointStatus is the health of one VictoriaLogs endpoint as tracked by the logger
+type EndpointStatus struct {
+	URL                 string `json:"url"`
+	Healthy             bool   `json:"healthy"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	LastError           string `json:"last_error,omitempty"`
+}
+
+type endpoint struct {
+	url       string
+	failures  int
+	downUntil time.Time
+	lastErr   string
+}
+
+// endpointPool picks the VictoriaLogs endpoint for each request. An endpoint that fails is
+// skipped for the cooldown, unless every endpoint is down.
+type endpointPool struct {
+	mu         sync.Mutex
+	endpoints  []*endpoint
+	roundRobin bool
+	next       int
+	cooldown   time.Duration
+}
+
+func newEndpointPool(urls []string, policy string, cooldown time.Duration) *endpointPool {
+	if cooldown <= 0 {
+		cooldown = defaultEndpointCooldown
+	}
+	p := &endpointPool{roundRobin: policy == EndpointRoundRobin, cooldown: cooldown}
+	for _, u := range urls {
+		p.endpoints = append(p.endpoints, &endpoint{url: u})
+	}
+	return p
+}
+
+// pick returns the next healthy endpoint according to the policy, or the one that comes
+// back first when all of them are down
+func (p *endpointPool) pick(now time.Time) *endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := len(p.endpoints)
+	start := 0
+	if p.roundRobin {
+		start = p.next
+		p.next = (p.next + 1) % n
+	}
+	for i := 0; i < n; i++ {
+		e := p.endpoints[(start+i)%n]
+		if !now.Before(e.downUntil) {
+			return e
+		}
+	}
+	first := p.endpoints[0]
+	for _, e := range p.endpoints[1:] {
+		if e.downUntil.Before(first.downUntil) {
+			first = e
+		}
+	}
+	return first
+}
+
+// report records the outcome of a request to e
+func (p *endpointPool) report(e *endpoint, err error, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		e.failures = 0
+		e.downUntil = time.Time{}
+		e.lastErr = ""
+		return
+	}
+	e.failures++
+	e.downUntil = now.Add(p.cooldown)
+	e.lastErr = err.Error()
+}
+
+// healthyAvailable reports whether any endpoint is outside its cooldown
+func (p *endpointPool) healthyAvailable(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, e := range p.endpoints {
+		if !now.Before(e.downUntil) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *endpointPool) status(now time.Time) []EndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]EndpointStatus, len(p.endpoints))
+	for i, e := range p.endpoints {
+		out[i] = EndpointStatus{
+			URL:                 e.url,
+			Healthy:             !now.Before(e.downUntil),
+			ConsecutiveFailures: e.failures,
+			LastError:           e.lastErr,
+		}
+	}
+	return out
+}
+
+// route is where a batch is delivered: the endpoints to try, the circuit breaker guarding them
+// and the stream fields sent to them
+type route struct {
+	endpoints    *endpointPool
+	breaker      *circuitBreaker
+	streamFields []string
+}
+
+// endpointURLs returns Endpoints, or VictoriaLogsURL when none are listed
+func (c *Config) endpointURLs() []string {
+	if len(c.Endpoints) > 0 {
+		return c.Endpoints
+	}
+	return []string{c.VictoriaLogsURL}
+}
+
+// newRoutes builds the routes for regular and audit batches, checking every URL. Audit batches
+// share the endpoints (and their health) unless AuditURL is set.
+func (c *Config) newRoutes() (insert, audit route, err error) {
+	switch c.EndpointPolicy {
+	case "", EndpointFailover, EndpointRoundRobin:
+	default:
+		return route{}, route{}, fmt.Errorf("unknown endpoint policy %q", c.EndpointPolicy)
+	}
+	urls := c.endpointURLs()
+	for _, u := range urls {
+		if _, err := c.ingestURL(u, c.StreamFields); err != nil {
+			return route{}, route{}, fmt.Errorf("invalid VictoriaLogs URL: %w", err)
+		}
+	}
+	insert = route{
+		endpoints:    newEndpointPool(urls, c.EndpointPolicy, c.EndpointCooldown),
+		breaker:      newCircuitBreaker(c.CircuitBreaker),
+		streamFields: c.StreamFields,
+	}
+	audit = route{endpoints: insert.endpoints, breaker: insert.breaker, streamFields: c.auditStreamFields()}
+	if c.AuditURL != "" {
+		if _, err := c.ingestURL(c.AuditURL, audit.streamFields); err != nil {
+			return route{}, route{}, fmt.Errorf("invalid audit URL: %w", err)
+		}
+		audit.endpoints = newEndpointPool([]string{c.AuditURL}, EndpointFailover, c.EndpointCooldown)
+		audit.breaker = newCircuitBreaker(c.CircuitBreaker)
+	}
+	return insert, audit, nil
+}
@@ -0,0 +1,23 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+)
+
+// defaultErrorHandler writes internal errors to stderr
+func defaultErrorHandler(err error) {
+	fmt.Fprintf(os.Stderr, "logger: %v\n", err)
+}
+
+func (c *Config) errorHandler() func(error) {
+	if c.ErrorHandler == nil {
+		return defaultErrorHandler
+	}
+	return c.ErrorHandler
+}
+
+// reportError hands a problem the caller cannot see, e.g. a failed delivery attempt, to Config.ErrorHandler
+func (v *VictoriaLogsLogger) reportError(err error) {
+	v.cfg().errorHandler()(err)
+}
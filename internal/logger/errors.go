@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Field names used for errors. "error" always holds the message of the outermost error.
+const (
+	ErrorKey      = "error"
+	ErrorTypeKey  = "error_type"
+	ErrorChainKey = "error_chain"
+)
+
+// ErrorFields describes err as fields: its message, concrete type and the messages of the
+// errors it wraps, outermost first, as found by errors.Unwrap. A nil error yields nil.
+func ErrorFields(err error) map[string]interface{} {
+	if err == nil {
+		return nil
+	}
+	fields := map[string]interface{}{
+		ErrorKey:     err.Error(),
+		ErrorTypeKey: fmt.Sprintf("%T", err),
+	}
+
+	var chain []string
+	for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(cause) {
+		chain = append(chain, fmt.Sprintf("%T: %s", cause, cause.Error()))
+	}
+	if len(chain) > 0 {
+		fields[ErrorChainKey] = chain
+	}
+	return fields
+}
+
+// WithError returns a child logger that attaches err, its type and its cause chain to every entry
+func (v *VictoriaLogsLogger) WithError(err error) Logger {
+	return v.WithFields(ErrorFields(err))
+}
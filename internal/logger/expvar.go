@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"expvar"
+	"sync"
+)
+
+// expvarLoggers maps each published name to the logger whose Stats it reports. expvar names
+// cannot be unpublished, so a logger created later with the same Config.ExpvarPrefix takes over.
+var (
+	expvarMu      sync.Mutex
+	expvarLoggers = make(map[string]*VictoriaLogsLogger)
+)
+
+// publishExpvar exposes Stats under name on /debug/vars
+func (v *VictoriaLogsLogger) publishExpvar(name string) {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if _, ok := expvarLoggers[name]; !ok && expvar.Get(name) == nil {
+		expvar.Publish(name, expvar.Func(func() interface{} {
+			expvarMu.Lock()
+			l := expvarLoggers[name]
+			expvarMu.Unlock()
+			if l == nil {
+				return nil
+			}
+			return l.Stats()
+		}))
+	}
+	expvarLoggers[name] = v
+}
+
+// unpublishExpvar stops reporting under name once the logger is closed
+func (v *VictoriaLogsLogger) unpublishExpvar(name string) {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if l := expvarLoggers[name]; l != nil && l.life == v.life {
+		expvarLoggers[name] = nil
+	}
+}
@@ -0,0 +1,80 @@
+package logger
+
+import "context"
+
+// Fields filled from the context by the default extractors
+const (
+	TraceIDKey = "trace_id"
+	UserIDKey  = "user_id"
+)
+
+// ContextExtractor pulls fields out of a request context. "trace_id" and "user_id" string
+// values populate LogEntry.TraceID and LogEntry.UserID; everything else is added to Fields.
+type ContextExtractor func(ctx context.Context) map[string]interface{}
+
+// ValueExtractor returns an extractor copying ctx.Value(key) into field when it is set
+func ValueExtractor(key interface{}, field string) ContextExtractor {
+	return func(ctx context.Context) map[string]interface{} {
+		val := ctx.Value(key)
+		if val == nil {
+			return nil
+		}
+		return map[string]interface{}{field: val}
+	}
+}
+
+// DefaultContextExtractors reads the trace and user IDs set with ContextWithTraceID and
+// ContextWithUserID (or the legacy plain string keys). They are used when Config.ContextExtractors is nil.
+func DefaultContextExtractors() []ContextExtractor {
+	return []ContextExtractor{IDExtractor}
+}
+
+// IDExtractor returns trace_id and user_id as found by TraceIDFromContext and UserIDFromContext
+func IDExtractor(ctx context.Context) map[string]interface{} {
+	traceID, userID := TraceIDFromContext(ctx), UserIDFromContext(ctx)
+	if traceID == "" && userID == "" {
+		return nil
+	}
+	fields := make(map[string]interface{}, 2)
+	if traceID != "" {
+		fields[TraceIDKey] = traceID
+	}
+	if userID != "" {
+		fields[UserIDKey] = userID
+	}
+	return fields
+}
+
+func (c *Config) contextExtractors() []ContextExtractor {
+	extractors := c.ContextExtractors
+	if extractors == nil {
+		extractors = DefaultContextExtractors()
+	}
+	if c.EnableOTel {
+		// Last, so an active span wins over IDs stored under plain keys
+		extractors = append(extractors[:len(extractors):len(extractors)], OTelExtractor)
+	}
+	return extractors
+}
+
+// enrichFromContext runs the extractors on ctx and copies their results into the entry
+func enrichFromContext(ctx context.Context, entry *LogEntry, extractors []ContextExtractor) {
+	if ctx == nil {
+		return
+	}
+	for _, extract := range extractors {
+		for k, val := range extract(ctx) {
+			switch s, isString := val.(string); {
+			case k == TraceIDKey && isString:
+				entry.TraceID = s
+			case k == UserIDKey && isString:
+				entry.UserID = s
+			default:
+				if entry.Fields == nil {
+					entry.Fields = make(map[string]interface{})
+				}
+				entry.Fields[k] = val
+			}
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileSink appends entries as JSON lines to a local file
+type FileSink struct {
+	*WriterSink
+	file io.WriteCloser
+}
+
+// NewFileSink opens (or creates) path for appending
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open log file: %w", err)
+	}
+	return &FileSink{WriterSink: NewWriterSink(f, nil), file: f}, nil
+}
+
+// RotationConfig controls when a rotating FileSink starts a new file and which old files it keeps
+type RotationConfig struct {
+	// MaxSize rotates once the file would grow past this many bytes; 0 disables size rotation
+	MaxSize int64 `yaml:"max_size"`
+	// Interval rotates files older than this, e.g. 24h for daily files; 0 disables time rotation
+	Interval time.Duration `yaml:"interval"`
+	// MaxAge deletes rotated files older than this; 0 keeps them regardless of age
+	MaxAge time.Duration `yaml:"max_age"`
+	// MaxBackups keeps at most this many rotated files; 0 keeps all
+	MaxBackups int `yaml:"max_backups"`
+	// Compress gzips rotated files in the background
+	Compress bool `yaml:"compress"`
+}
+
+// NewRotatingFileSink is NewFileSink with rotation. Rotated files are renamed with a timestamp
+// before the extension, e.g. app-20241021T063000.000.jsonl(.gz).
+func NewRotatingFileSink(path string, rotation RotationConfig) (*FileSink, error) {
+	rf := &rotatingFile{path: path, config: rotation}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return &FileSink{WriterSink: NewWriterSink(rf, nil), file: rf}, nil
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// backupTimeFormat sorts lexically in time order
+const backupTimeFormat = "20060102T150405.000"
+
+// rotatingFile is an io.WriteCloser over path that rotates according to its config
+type rotatingFile struct {
+	path     string
+	config   RotationConfig
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	// compressing tracks background gzip and cleanup jobs so Close can wait for them;
+	// jobMu runs them one at a time so cleanup never races a compression
+	compressing sync.WaitGroup
+	jobMu       sync.Mutex
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+	r.file = f
+	r.size = info.Size()
+	r.openedAt = time.Now()
+	if r.size > 0 {
+		// Continue an existing file: its age counts from its last modification
+		r.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+// Write is called with the WriterSink lock held, so it needs no locking of its own
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.shouldRotate(len(p)) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) shouldRotate(next int) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.config.MaxSize > 0 && r.size+int64(next) > r.config.MaxSize {
+		return true
+	}
+	return r.config.Interval > 0 && time.Since(r.openedAt) >= r.config.Interval
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("close log file: %w", err)
+	}
+	backup := r.backupName(time.Now())
+	if err := os.Rename(r.path, backup); err != nil {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	r.compressing.Add(1)
+	go func() {
+		defer r.compressing.Done()
+		r.jobMu.Lock()
+		defer r.jobMu.Unlock()
+		if r.config.Compress {
+			if err := gzipFile(backup); err != nil {
+				defaultErrorHandler(fmt.Errorf("compress %s: %w", backup, err))
+			}
+		}
+		r.removeOldBackups()
+	}()
+	return nil
+}
+
+// backupName inserts the rotation time before the extension of path
+func (r *rotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(r.path)
+	return strings.TrimSuffix(r.path, ext) + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// removeOldBackups deletes rotated files beyond MaxBackups or older than MaxAge
+func (r *rotatingFile) removeOldBackups() {
+	if r.config.MaxBackups <= 0 && r.config.MaxAge <= 0 {
+		return
+	}
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(r.path, ext) + "-"
+	matches, err := filepath.Glob(prefix + "*" + ext + "*")
+	if err != nil {
+		return
+	}
+	var backups []string
+	for _, name := range matches {
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err == nil {
+			backups = append(backups, name)
+		}
+	}
+	// Newest first
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	for i, name := range backups {
+		expired := false
+		if r.config.MaxAge > 0 {
+			if info, err := os.Stat(name); err == nil && time.Since(info.ModTime()) > r.config.MaxAge {
+				expired = true
+			}
+		}
+		if expired || (r.config.MaxBackups > 0 && i >= r.config.MaxBackups) {
+			if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+				defaultErrorHandler(fmt.Errorf("remove %s: %w", name, err))
+			}
+		}
+	}
+}
+
+func (r *rotatingFile) Close() error {
+	err := r.file.Close()
+	r.compressing.Wait()
+	return err
+}
+
+// gzipFile replaces path with path.gz
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// flattenInto copies fields into dst, turning nested maps and structs into dotted keys,
+// e.g. {"http": {"request": {"method": "GET"}}} becomes {"http.request.method": "GET"}
+func flattenInto(dst map[string]interface{}, prefix string, fields map[string]interface{}) {
+	for k, val := range fields {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := nestedFields(val); ok {
+			flattenInto(dst, key, nested)
+			continue
+		}
+		dst[key] = val
+	}
+}
+
+// nestedFields returns val as a fields map if it is a map with string keys or a struct.
+// Structs go through encoding/json so their json tags name the keys. Values that marshal
+// themselves (errors, times, Stringers...) are left alone.
+func nestedFields(val interface{}) (map[string]interface{}, bool) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		return v, true
+	case error, json.Marshaler, encoding.TextMarshaler, fmt.Stringer:
+		return nil, false
+	}
+
+	rv := reflect.ValueOf(val)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		out := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = iter.Value().Interface()
+		}
+		return out, true
+	case reflect.Struct:
+		data, err := json.Marshal(rv.Interface())
+		if err != nil {
+			return nil, false
+		}
+		var out map[string]interface{}
+		if err := json.Unmarshal(data, &out); err != nil {
+			return nil, false
+		}
+		return out, true
+	}
+	return nil, false
+}
@@ -0,0 +1,94 @@
+package logger_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+	"github.com/anhdnyopaz/go_victorialog/internal/logger/logtest"
+)
+
+// newAsyncLogger returns a buffered logger pointed at fake, closed when the test ends
+func newAsyncLogger(t *testing.T, fake *logtest.FakeVictoriaLogs, opts ...func(*logger.Config)) *logger.VictoriaLogsLogger {
+	t.Helper()
+	config := logtest.SyncConfig(fake.InsertURL())
+	config.Async = true
+	config.FlushInterval = time.Hour
+	config.DrainTimeout = 5 * time.Second
+	config.ErrorHandler = func(error) {}
+	for _, opt := range opts {
+		opt(config)
+	}
+	l, err := logger.NewVictoriaLogsLogger(config)
+	if err != nil {
+		t.Fatalf("create logger: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+func TestFlushWaitsForDelivery(t *testing.T) {
+	fake := logtest.NewFakeVictoriaLogs(t)
+	l := newAsyncLogger(t, fake)
+	l.Info(context.Background(), "Buffered", nil)
+
+	if err := l.Flush(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if n := len(fake.Entries()); n != 1 {
+		t.Errorf("%d entries delivered when Flush returned, want 1", n)
+	}
+}
+
+func TestFlushDeadline(t *testing.T) {
+	fake := logtest.NewFakeVictoriaLogs(t)
+	fake.SetDelay(300 * time.Millisecond)
+	l := newAsyncLogger(t, fake)
+	l.Info(context.Background(), "Stuck", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := l.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("flush = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestFlushReturnsRejection(t *testing.T) {
+	fake := logtest.NewFakeVictoriaLogs(t)
+	fake.RespondWith(http.StatusBadRequest)
+	l := newAsyncLogger(t, fake, func(c *logger.Config) { c.FallbackSink = &recordingSink{} })
+	l.Info(context.Background(), "Rejected", nil)
+
+	err := l.Flush(context.Background())
+	var httpErr *logger.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("flush = %v, want an HTTPError with status 400", err)
+	}
+}
+
+func TestFlushAndCloseOnCancelledChild(t *testing.T) {
+	fake := logtest.NewFakeVictoriaLogs(t)
+	l := newAsyncLogger(t, fake)
+	ctx, cancel := context.WithCancel(context.Background())
+	child := l.WithContext(ctx)
+	cancel()
+
+	child.Info(context.Background(), "After the request ended", nil)
+	if err := child.Flush(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if n := len(fake.Entries()); n != 1 {
+		t.Fatalf("%d entries delivered by Flush on the child, want 1", n)
+	}
+
+	child.Info(context.Background(), "Before close", nil)
+	if err := child.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if n := len(fake.Entries()); n != 2 {
+		t.Errorf("%d entries delivered by Close on the child, want 2", n)
+	}
+}
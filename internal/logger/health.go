@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// unhealthyBufferUtilization is the buffer fill ratio above which the logger reports itself unhealthy
+const unhealthyBufferUtilization = 0.9
+
+type HealthStatus struct {
+	Healthy           bool    `json:"healthy"`
+	Reachable         bool    `json:"victoria_logs_reachable"`
+	BufferUsed        int     `json:"buffer_used"`
+	BufferCapacity    int     `json:"buffer_capacity"`
+	BufferUtilization float64 `json:"buffer_utilization"`
+	Error             string  `json:"error,omitempty"`
+	// Endpoints is set when Config.Endpoints lists several VictoriaLogs replicas
+	Endpoints []EndpointStatus `json:"endpoints,omitempty"`
+	// Circuit is the circuit breaker state when Config.CircuitBreaker is enabled
+	Circuit string `json:"circuit,omitempty"`
+	// Paused is set while Config.HealthCheckInterval probes find VictoriaLogs unreachable
+	Paused bool `json:"delivery_paused,omitempty"`
+	// Stats are the pipeline counters, see VictoriaLogsLogger.Stats
+	Stats Stats `json:"stats"`
+}
+
+// Health checks VictoriaLogs connectivity via its /health endpoint and reports buffer utilization.
+// With several endpoints each one is probed, which also updates its tracked health, and
+// VictoriaLogs counts as reachable when any of them is.
+func (v *VictoriaLogsLogger) Health(ctx context.Context) HealthStatus {
+	status := HealthStatus{
+		BufferUsed:     len(v.buffer) + len(v.priority),
+		BufferCapacity: cap(v.buffer) + cap(v.priority),
+	}
+	if status.BufferCapacity > 0 {
+		status.BufferUtilization = float64(status.BufferUsed) / float64(status.BufferCapacity)
+	}
+
+	if v.output != nil {
+		// Entries go to the output sink, VictoriaLogs is not used
+		status.Reachable = true
+	} else if err := v.pingEndpoints(ctx); err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Reachable = true
+	}
+	settings := v.settings.Load()
+	if len(settings.config.Endpoints) > 0 {
+		status.Endpoints = settings.insertRoute.endpoints.status(settings.config.clock().Now())
+	}
+	status.Circuit = settings.insertRoute.breaker.currentState()
+	status.Paused = v.life.paused.Load()
+	status.Stats = v.Stats()
+	status.Healthy = status.Reachable && status.BufferUtilization < unhealthyBufferUtilization
+	if status.Reachable && !status.Healthy {
+		status.Error = fmt.Sprintf("buffer %.0f%% full", status.BufferUtilization*100)
+	}
+	return status
+}
+
+// startHealthProbe pings VictoriaLogs every HealthCheckInterval, pausing delivery while it is down
+func (v *VictoriaLogsLogger) startHealthProbe() {
+	v.life.wg.Add(1)
+	go func() {
+		defer v.life.wg.Done()
+		ticker := v.cfg().clock().NewTicker(v.cfg().HealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C():
+			case <-v.life.ctx.Done():
+				return
+			}
+			ctx, cancel := v.requestContext()
+			err := v.pingEndpoints(ctx)
+			cancel()
+			v.setPaused(err)
+		}
+	}()
+}
+
+// setPaused pauses delivery when the probe failed with err and resumes it when err is nil,
+// reporting the pause once
+func (v *VictoriaLogsLogger) setPaused(err error) {
+	paused := err != nil
+	if v.life.paused.Swap(paused) == paused {
+		return
+	}
+	if paused {
+		v.reportError(fmt.Errorf("VictoriaLogs unreachable, pausing delivery: %w", err))
+	}
+	select {
+	case v.life.pauseChanged <- struct{}{}:
+	default:
+	}
+}
+
+// pingEndpoints probes every endpoint and succeeds when at least one is reachable
+func (v *VictoriaLogsLogger) pingEndpoints(ctx context.Context) error {
+	pool := v.settings.Load().insertRoute.endpoints
+	var errs []error
+	for _, ep := range pool.endpoints {
+		err := v.ping(ctx, ep.url)
+		pool.report(ep, err, v.cfg().clock().Now())
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (v *VictoriaLogsLogger) ping(ctx context.Context, base string) error {
+	healthURL, err := healthURL(base)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
+	if err != nil {
+		return err
+	}
+	v.cfg().applyHeaders(req)
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("VictoriaLogs health check returned status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// healthURL returns the /health endpoint on the same host as the insert URL base
+func healthURL(base string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	u.Path = "/health"
+	u.RawQuery = ""
+	return u.String(), nil
+}
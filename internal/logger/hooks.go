@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Hook runs on every entry before it is buffered; entry.Fields is never nil. It may mutate the entry, e.g. to redact or
+// enrich it, and vetoes it by returning ErrDropEntry. Any other error is reported and the
+// entry is kept, so a broken enrichment hook cannot silently lose logs.
+type Hook func(entry *LogEntry) error
+
+// ErrDropEntry is returned by a Hook to drop the entry
+var ErrDropEntry = errors.New("logger: entry dropped by hook")
+
+// hookChain holds the hooks shared by a logger and its children
+type hookChain struct {
+	mu    sync.RWMutex
+	hooks []Hook
+}
+
+func newHookChain(hooks []Hook) *hookChain {
+	return &hookChain{hooks: append([]Hook(nil), hooks...)}
+}
+
+func (h *hookChain) add(hook Hook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, hook)
+}
+
+// run applies the hooks in order and reports whether the entry should be kept; hook errors
+// other than ErrDropEntry go to onError
+func (h *hookChain) run(entry *LogEntry, onError func(error)) bool {
+	h.mu.RLock()
+	hooks := h.hooks
+	h.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(entry); err != nil {
+			if errors.Is(err, ErrDropEntry) {
+				return false
+			}
+			onError(fmt.Errorf("hook: %w", err))
+		}
+	}
+	return true
+}
+
+// AddHook appends a hook to the chain shared by this logger and all loggers derived from it
+func (v *VictoriaLogsLogger) AddHook(hook Hook) {
+	v.hooks.add(hook)
+}
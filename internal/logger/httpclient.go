@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// newHTTPClient returns the client used for VictoriaLogs requests: Config.HTTPClient as is, or a
+// client with Config.Timeout over Config.Transport, with a dedicated transport when TLS or a
+// proxy is configured
+func newHTTPClient(config *Config) (*http.Client, error) {
+	tlsCfg, err := config.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := config.proxy()
+	if err != nil {
+		return nil, err
+	}
+	if config.HTTPClient != nil {
+		if tlsCfg != nil || proxy != nil {
+			return nil, errors.New("TLS and proxy options cannot be combined with HTTPClient, configure its transport instead")
+		}
+		return config.HTTPClient, nil
+	}
+
+	client := &http.Client{Timeout: config.Timeout, Transport: config.Transport}
+	if tlsCfg == nil && proxy == nil {
+		return client, nil
+	}
+	base := http.DefaultTransport
+	if config.Transport != nil {
+		base = config.Transport
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return nil, errors.New("TLS and proxy options need Transport to be an *http.Transport")
+	}
+	transport = transport.Clone()
+	if tlsCfg != nil {
+		transport.TLSClientConfig = tlsCfg
+	}
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	client.Transport = transport
+	return client, nil
+}
+
+// proxy parses Config.ProxyURL, returning nil when it is empty
+func (c *Config) proxy() (*url.URL, error) {
+	if c.ProxyURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(c.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme and host are required", c.ProxyURL)
+	}
+	return u, nil
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorBody caps how much of a rejected response body is kept in HTTPError
+const maxErrorBody = 1024
+
+// HTTPError is returned when VictoriaLogs answers with a status of 400 or above
+type HTTPError struct {
+	StatusCode int
+	// Body is the start of the response body, which usually explains the rejection
+	Body string
+	// RetryAfter is the delay requested by a Retry-After header, zero when absent
+	RetryAfter time.Duration
+}
+
+func (e *HTTPError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("VictoriaLogs returned status code %d", e.StatusCode)
+	}
+	return fmt.Sprintf("VictoriaLogs returned status code %d: %s", e.StatusCode, e.Body)
+}
+
+// Temporary reports whether the request may succeed when retried: 429 and 5xx responses
+func (e *HTTPError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// newHTTPError reads the body and Retry-After header of a rejected response
+func newHTTPError(resp *http.Response, now time.Time) *HTTPError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return &HTTPError{
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(string(body)),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), now),
+	}
+}
+
+// parseRetryAfter accepts both forms of Retry-After: delay seconds and an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// retryable reports whether a failed delivery is worth retrying. Transport errors are;
+// responses only for 429 and 5xx, since 400, 401 or 413 will be rejected again.
+func retryable(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Temporary()
+	}
+	return err != nil
+}
+
+// retryAfter returns the delay VictoriaLogs asked for, if any
+func retryAfter(err error) time.Duration {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.RetryAfter
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	mathrand "math/rand"
+	"sync"
+)
+
+// IDGenerator produces trace, request and batch identifiers
+type IDGenerator interface {
+	NewID() string
+}
+
+// RandomIDGenerator returns 128-bit random hex IDs
+type RandomIDGenerator struct{}
+
+func (RandomIDGenerator) NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("logger: crypto/rand failed: " + err.Error())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// DeterministicIDGenerator returns a reproducible sequence of IDs for a given seed, for tests
+type DeterministicIDGenerator struct {
+	mu  sync.Mutex
+	rng *mathrand.Rand
+}
+
+// NewDeterministicIDGenerator returns a generator whose output depends only on seed
+func NewDeterministicIDGenerator(seed int64) *DeterministicIDGenerator {
+	return &DeterministicIDGenerator{rng: mathrand.New(mathrand.NewSource(seed))}
+}
+
+func (d *DeterministicIDGenerator) NewID() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var b [16]byte
+	d.rng.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func (c *Config) idGenerator() IDGenerator {
+	if c.IDGenerator == nil {
+		return RandomIDGenerator{}
+	}
+	return c.IDGenerator
+}
@@ -1,6 +1,9 @@
 package logger
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 type LogLevel int
 
@@ -9,6 +12,7 @@ const (
 	INFO
 	WARN
 	ERROR
+	PANIC
 	FATAL
 )
 
@@ -22,6 +26,8 @@ func (l LogLevel) String() string {
 		return "WARN"
 	case ERROR:
 		return "ERROR"
+	case PANIC:
+		return "PANIC"
 	case FATAL:
 		return "FATAL"
 	default:
@@ -30,13 +36,20 @@ func (l LogLevel) String() string {
 }
 
 type LogEntry struct {
-	Level     LogLevel               `json:"level"`
-	Message   string                 `json:"message"`
-	Timestamp int64                  `json:"timestamp"`
-	Service   string                 `json:"service"`
-	TraceID   string                 `json:"trace_id,omitempty"`
-	UserID    string                 `json:"user_id,omitempty"`
-	Fields    map[string]interface{} `json:"fields,omitempty"`
+	Level     LogLevel `json:"level"`
+	Message   string   `json:"message"`
+	Timestamp int64    `json:"timestamp"`
+	Service   string   `json:"service"`
+	TraceID   string   `json:"trace_id,omitempty"`
+	UserID    string   `json:"user_id,omitempty"`
+	// Stream is AuditStream for entries written with Audit and empty otherwise
+	Stream string `json:"stream,omitempty"`
+	// Tenant the entry is sent to; BatchLog fills in the logger's tenant when it is zero
+	Tenant Tenant                 `json:"-"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+
+	// seq is the position of the entry in the disk queue, zero when it did not come from one
+	seq uint64
 }
 
 type Logger interface {
@@ -45,10 +58,40 @@ type Logger interface {
 	Warn(ctx context.Context, msg string, fields map[string]interface{})
 	Error(ctx context.Context, msg string, fields map[string]interface{})
 	Fatal(ctx context.Context, msg string, fields map[string]interface{})
+	// Panic logs and delivers the entry, then panics with msg
+	Panic(ctx context.Context, msg string, fields map[string]interface{})
+
+	// Debugf and friends format the message with fmt.Sprintf. If the last argument is a
+	// map[string]interface{} it is used as the entry fields instead of a format argument.
+	Debugf(ctx context.Context, format string, args ...interface{})
+	Infof(ctx context.Context, format string, args ...interface{})
+	Warnf(ctx context.Context, format string, args ...interface{})
+	Errorf(ctx context.Context, format string, args ...interface{})
+	Fatalf(ctx context.Context, format string, args ...interface{})
+	Panicf(ctx context.Context, format string, args ...interface{})
+
+	// Debugw and friends take fields as alternating keys and values, e.g. "user_id", id, "attempt", 2
+	Debugw(ctx context.Context, msg string, keysAndValues ...interface{})
+	Infow(ctx context.Context, msg string, keysAndValues ...interface{})
+	Warnw(ctx context.Context, msg string, keysAndValues ...interface{})
+	Errorw(ctx context.Context, msg string, keysAndValues ...interface{})
+	Fatalw(ctx context.Context, msg string, keysAndValues ...interface{})
+	Panicw(ctx context.Context, msg string, keysAndValues ...interface{})
+
+	// Enabled reports whether entries at level would be logged, so callers can skip building
+	// expensive fields: if l.Enabled(DEBUG) { ... }
+	Enabled(level LogLevel) bool
+
+	// Audit records a compliance event on the audit stream. It is never filtered, sampled or
+	// dropped and returns once the entry was delivered, or with the delivery error.
+	Audit(ctx context.Context, action string, fields map[string]interface{}) error
 
-	// BatchLog Batch operations
-	BatchLog(entries []LogEntry) error
-	Flush() error
+	// BatchLog logs caller-built entries and returns how many were accepted; entries[n:] were not
+	// logged. BatchLogContext waits for room in the buffer until ctx ends instead of giving up.
+	BatchLog(entries []LogEntry) (int, error)
+	BatchLogContext(ctx context.Context, entries []LogEntry) (int, error)
+	// Flush sends buffered entries and waits until they were delivered or ctx ends
+	Flush(ctx context.Context) error
 	Close() error
 }
 
@@ -56,4 +99,71 @@ type ContextLogger interface {
 	WithContext(ctx context.Context) Logger
 	WithFields(fields map[string]interface{}) Logger
 	WithService(service string) Logger
+	WithError(err error) Logger
+	WithName(name string) Logger
+	WithTenant(accountID, projectID string) Logger
+}
+
+// LogAt logs msg on l at level, for adapters that pick the level at run time. It never panics
+// or exits: loggers with a Log method, like VictoriaLogsLogger, get the level as is and others
+// log PANIC and FATAL with Error.
+func LogAt(l Logger, ctx context.Context, level LogLevel, msg string, fields map[string]interface{}) {
+	if ll, ok := l.(interface {
+		Log(ctx context.Context, level LogLevel, msg string, fields map[string]interface{})
+	}); ok {
+		ll.Log(ctx, level, msg, fields)
+		return
+	}
+	switch level {
+	case DEBUG:
+		l.Debug(ctx, msg, fields)
+	case INFO:
+		l.Info(ctx, msg, fields)
+	case WARN:
+		l.Warn(ctx, msg, fields)
+	default:
+		l.Error(ctx, msg, fields)
+	}
+}
+
+// LoggerNameKey is the field holding the hierarchical name set with WithName
+const LoggerNameKey = "logger"
+
+// formatMessage applies fmt.Sprintf to format and args, treating a trailing fields map as fields
+func formatMessage(format string, args []interface{}) (string, map[string]interface{}) {
+	var fields map[string]interface{}
+	if n := len(args); n > 0 {
+		if f, ok := args[n-1].(map[string]interface{}); ok {
+			fields = f
+			args = args[:n-1]
+		}
+	}
+	if len(args) == 0 {
+		return format, fields
+	}
+	return fmt.Sprintf(format, args...), fields
+}
+
+// badKey holds a trailing value that has no key in a keysAndValues list
+const badKey = "!BADKEY"
+
+// fieldsFromKeysAndValues builds a fields map from alternating keys and values.
+// Non-string keys are stringified and a dangling last element is stored under "!BADKEY".
+func fieldsFromKeysAndValues(keysAndValues []interface{}) map[string]interface{} {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+	fields := make(map[string]interface{}, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 == len(keysAndValues) {
+			fields[badKey] = keysAndValues[i]
+			break
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		fields[key] = keysAndValues[i+1]
+	}
+	return fields
 }
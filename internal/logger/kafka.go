@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// KafkaConfig configures a KafkaSink
+type KafkaConfig struct {
+	Brokers []string `yaml:"brokers"`
+	Topic   string   `yaml:"topic"`
+	// KeyField names the field used as the record key, so related entries land on one partition.
+	// Empty means trace_id; entries without a value are sent unkeyed.
+	KeyField string `yaml:"key_field"`
+	// Options are passed to kgo.NewClient after the brokers, e.g. SASL or TLS settings
+	Options []kgo.Opt `yaml:"-"`
+}
+
+// KafkaSink publishes each entry as a VictoriaLogs JSON record to a Kafka topic, so logs can be
+// buffered or fanned out through Kafka before ingestion
+type KafkaSink struct {
+	client   *kgo.Client
+	topic    string
+	keyField string
+	encoder  Encoder
+}
+
+// NewKafkaSink creates the producer client; brokers are contacted on the first write
+func NewKafkaSink(config KafkaConfig) (*KafkaSink, error) {
+	if len(config.Brokers) == 0 || config.Topic == "" {
+		return nil, errors.New("kafka sink needs brokers and a topic")
+	}
+	opts := append([]kgo.Opt{kgo.SeedBrokers(config.Brokers...)}, config.Options...)
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create kafka client: %w", err)
+	}
+	keyField := config.KeyField
+	if keyField == "" {
+		keyField = TraceIDKey
+	}
+	return &KafkaSink{client: client, topic: config.Topic, keyField: keyField, encoder: JSONLinesEncoder{}}, nil
+}
+
+// Write produces the entries and waits until Kafka acknowledged all of them
+func (s *KafkaSink) Write(ctx context.Context, entries []LogEntry) error {
+	records := make([]*kgo.Record, 0, len(entries))
+	for _, entry := range entries {
+		var buf bytes.Buffer
+		if err := s.encoder.Encode(&buf, entry); err != nil {
+			return err
+		}
+		record := &kgo.Record{Topic: s.topic, Value: bytes.TrimSuffix(buf.Bytes(), []byte("\n"))}
+		if key := s.key(entry); key != "" {
+			record.Key = []byte(key)
+		}
+		records = append(records, record)
+	}
+	return s.client.ProduceSync(ctx, records...).FirstErr()
+}
+
+func (s *KafkaSink) key(entry LogEntry) string {
+	if s.keyField == TraceIDKey && entry.TraceID != "" {
+		return entry.TraceID
+	}
+	if s.keyField == UserIDKey && entry.UserID != "" {
+		return entry.UserID
+	}
+	if val, ok := entry.Fields[s.keyField]; ok && val != nil {
+		return fmt.Sprint(val)
+	}
+	return ""
+}
+
+// Close flushes buffered records and closes the client
+func (s *KafkaSink) Close() error {
+	err := s.client.Flush(context.Background())
+	s.client.Close()
+	return err
+}
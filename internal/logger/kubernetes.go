@@ -0,0 +1,160 @@
+package logger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Environment variables LoadKubernetesMetadata reads, set from the downward API:
+//
+//	env:
+//	  - name: POD_NAME
+//	    valueFrom: {fieldRef: {fieldPath: metadata.name}}
+//	  - name: POD_NAMESPACE
+//	    valueFrom: {fieldRef: {fieldPath: metadata.namespace}}
+//	  - name: NODE_NAME
+//	    valueFrom: {fieldRef: {fieldPath: spec.nodeName}}
+//	  - name: CONTAINER_NAME
+//	    value: api
+const (
+	EnvPodName       = "POD_NAME"
+	EnvPodNamespace  = "POD_NAMESPACE"
+	EnvNodeName      = "NODE_NAME"
+	EnvContainerName = "CONTAINER_NAME"
+)
+
+// DefaultPodLabelsPath is where a downwardAPI volume mounted at /etc/podinfo exposes the pod
+// labels, with an item {path: labels, fieldRef: {fieldPath: metadata.labels}}
+const DefaultPodLabelsPath = "/etc/podinfo/labels"
+
+// serviceAccountNamespacePath holds the pod namespace when a service account token is mounted
+const serviceAccountNamespacePath = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// KubernetesMetadata describes the pod the process runs in
+type KubernetesMetadata struct {
+	Pod       string
+	Namespace string
+	Node      string
+	Container string
+	Labels    map[string]string
+}
+
+// LoadKubernetesMetadata reads the pod metadata from the Env* variables and the labels file at
+// labelsPath, "" meaning DefaultPodLabelsPath. The pod name falls back to HOSTNAME and the
+// namespace to the service account's. A missing labels file is not an error.
+func LoadKubernetesMetadata(labelsPath string) (KubernetesMetadata, error) {
+	md := KubernetesMetadata{
+		Pod:       os.Getenv(EnvPodName),
+		Namespace: os.Getenv(EnvPodNamespace),
+		Node:      os.Getenv(EnvNodeName),
+		Container: os.Getenv(EnvContainerName),
+	}
+	if md.Pod == "" {
+		md.Pod = os.Getenv("HOSTNAME")
+	}
+	if md.Namespace == "" {
+		if data, err := os.ReadFile(serviceAccountNamespacePath); err == nil {
+			md.Namespace = strings.TrimSpace(string(data))
+		}
+	}
+
+	if labelsPath == "" {
+		labelsPath = DefaultPodLabelsPath
+	}
+	labels, err := readPodLabels(labelsPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return md, err
+	}
+	md.Labels = labels
+	return md, nil
+}
+
+// readPodLabels parses the downward API format, one key="quoted value" per line
+func readPodLabels(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	labels := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		key, quoted, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid pod label line %q in %s", line, path)
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pod label value %s in %s: %w", quoted, path, err)
+		}
+		labels[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return labels, nil
+}
+
+// KubernetesKey is the field holding the pod metadata, flattened to k8s.pod, k8s.namespace,
+// k8s.node, k8s.container and k8s.labels.<name>
+const KubernetesKey = "k8s"
+
+// KubernetesEnricher adds md to every entry under KubernetesKey, so logs can be filtered and
+// joined by pod in LogsQL, e.g. `k8s.namespace:prod k8s.labels.app:api` with TopLevelFields
+// (`fields.k8s.namespace` otherwise). Empty values are left out and an entry that already has
+// the field keeps it.
+//
+//	md, err := logger.LoadKubernetesMetadata("")
+//	l, err := logger.Builder().WithEnricher(logger.KubernetesEnricher(md)).Build()
+func KubernetesEnricher(md KubernetesMetadata) Enricher {
+	base := map[string]string{}
+	for name, value := range map[string]string{
+		"pod":       md.Pod,
+		"namespace": md.Namespace,
+		"node":      md.Node,
+		"container": md.Container,
+	} {
+		if value != "" {
+			base[name] = value
+		}
+	}
+	labels := make(map[string]string, len(md.Labels))
+	for name, value := range md.Labels {
+		labels[name] = value
+	}
+	if len(base) == 0 && len(labels) == 0 {
+		return func(*LogEntry) {}
+	}
+
+	return func(entry *LogEntry) {
+		if _, ok := entry.Fields[KubernetesKey]; ok {
+			return
+		}
+		// A fresh map per entry, so later steps can change one entry's metadata safely
+		fields := make(map[string]interface{}, len(base)+1)
+		for name, value := range base {
+			fields[name] = value
+		}
+		if len(labels) > 0 {
+			entryLabels := make(map[string]interface{}, len(labels))
+			for name, value := range labels {
+				entryLabels[name] = value
+			}
+			fields["labels"] = entryLabels
+		}
+		if entry.Fields == nil {
+			entry.Fields = make(map[string]interface{})
+		}
+		entry.Fields[KubernetesKey] = fields
+	}
+}
@@ -0,0 +1,22 @@
+package logger
+
+// LazyValue is a field value computed only when the entry is actually going to be logged
+type LazyValue func() interface{}
+
+// Lazy wraps an expensive field computation so it is skipped for entries that are filtered out:
+//
+//	vlLogger.Debug(ctx, "Cache state", map[string]interface{}{
+//	    "stats": logger.Lazy(func() interface{} { return cache.Stats() }),
+//	})
+func Lazy(f func() interface{}) LazyValue {
+	return f
+}
+
+// resolveLazy replaces LazyValue fields with their results
+func resolveLazy(fields map[string]interface{}) {
+	for k, val := range fields {
+		if lazy, ok := val.(LazyValue); ok {
+			fields[k] = lazy()
+		}
+	}
+}
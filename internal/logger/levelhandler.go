@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// levelPayload is the body of LevelHandler requests and responses
+type levelPayload struct {
+	Level string `json:"level"`
+	// Duration, when set on PUT, restores the previous level after it, e.g. "15m"
+	Duration string `json:"duration,omitempty"`
+}
+
+// LevelHandler lets operators read and change the level of the logger and every logger derived
+// from it at runtime. GET returns {"level":"INFO"}. PUT takes {"level":"debug"}, or the level
+// and duration form or query parameters, and answers with the new level; with a duration such
+// as "15m" the previous level is restored after it unless the level was changed again.
+//
+// The handler does no authentication: serve it on an internal or localhost-only listener, or
+// behind an auth check, never on a public API router.
+func (v *VictoriaLogsLogger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			if err := v.setLevelFromRequest(r); err != nil {
+				writeLevelResponse(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			writeLevelResponse(w, http.StatusMethodNotAllowed, map[string]string{"error": "only GET and PUT are supported"})
+			return
+		}
+		writeLevelResponse(w, http.StatusOK, levelPayload{Level: v.GetLevel().String()})
+	})
+}
+
+func (v *VictoriaLogsLogger) setLevelFromRequest(r *http.Request) error {
+	var req levelPayload
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return fmt.Errorf("invalid body: %w", err)
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+		req.Level, req.Duration = r.Form.Get("level"), r.Form.Get("duration")
+	}
+	level, err := ParseLevel(req.Level)
+	if err != nil {
+		return err
+	}
+	var d time.Duration
+	if req.Duration != "" {
+		if d, err = time.ParseDuration(req.Duration); err != nil || d <= 0 {
+			return fmt.Errorf("invalid duration %q", req.Duration)
+		}
+	}
+
+	previous := v.level.Swap(int32(level))
+	if d > 0 {
+		go func() {
+			select {
+			case <-v.cfg().clock().After(d):
+				v.level.CompareAndSwap(int32(level), previous)
+			case <-v.life.ctx.Done():
+			}
+		}()
+	}
+	return nil
+}
+
+func writeLevelResponse(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
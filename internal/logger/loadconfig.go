@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// LoadConfig reads a YAML or JSON config file (by its .json extension) using the yaml tag
+// names, e.g. victoria_logs_url or batch_size. Keys missing from the file keep their
+// DefaultConfig values, durations are written as "5s", and levels as "info" or "ERROR".
+// ${VAR} is replaced with the environment variable VAR and ${VAR:-default} falls back to
+// default when VAR is unset or empty. Unknown keys are an error.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config, err := ParseConfig(data, strings.EqualFold(filepath.Ext(path), ".json"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// ParseConfig is LoadConfig for a config already in memory
+func ParseConfig(data []byte, isJSON bool) (*Config, error) {
+	data = expandEnv(data)
+	if isJSON {
+		// JSON is valid YAML except for tab indentation, so it goes through a generic value first
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		var err error
+		if data, err = yaml.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	config := DefaultConfig()
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err := config.validateFieldPolicies(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnv replaces ${VAR} and ${VAR:-default}; other uses of $ are left alone
+func expandEnv(data []byte) []byte {
+	return envRef.ReplaceAllFunc(data, func(ref []byte) []byte {
+		m := envRef.FindSubmatch(ref)
+		if value := os.Getenv(string(m[1])); value != "" || m[2] == nil {
+			return []byte(value)
+		}
+		return m[3]
+	})
+}
+
+// ParseLevel returns the level named s, case-insensitively; "warning" is accepted for WARN
+func ParseLevel(s string) (LogLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "DEBUG":
+		return DEBUG, nil
+	case "INFO":
+		return INFO, nil
+	case "WARN", "WARNING":
+		return WARN, nil
+	case "ERROR":
+		return ERROR, nil
+	case "PANIC":
+		return PANIC, nil
+	case "FATAL":
+		return FATAL, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", s)
+}
+
+// UnmarshalYAML accepts a level name or its number
+func (l *LogLevel) UnmarshalYAML(node *yaml.Node) error {
+	if n, err := strconv.Atoi(node.Value); err == nil && node.Tag == "!!int" {
+		*l = LogLevel(n)
+		return nil
+	}
+	level, err := ParseLevel(node.Value)
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
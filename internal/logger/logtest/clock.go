@@ -0,0 +1,129 @@
+package logtest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+)
+
+// FakeClock is a logger.Clock that only moves when Advance is called
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+	changed chan struct{}
+}
+
+type fakeWaiter struct {
+	next   time.Time
+	period time.Duration // zero for one-shot timers
+	ch     chan time.Time
+	done   bool
+}
+
+// NewFakeClock returns a FakeClock starting at now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now, changed: make(chan struct{})}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) logger.Ticker {
+	if d <= 0 {
+		panic("logtest: non-positive interval for NewTicker")
+	}
+	w := c.addWaiter(d, d)
+	return &fakeTicker{clock: c, w: w}
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.addWaiter(d, 0).ch
+}
+
+// Sleep blocks until another goroutine advances the clock by at least d
+func (c *FakeClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+// Advance moves the clock forward and fires every timer and ticker that became due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	active := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.done {
+			continue
+		}
+		if !w.next.After(c.now) {
+			select {
+			case w.ch <- c.now:
+			default:
+			}
+			if w.period == 0 {
+				w.done = true
+				continue
+			}
+			for !w.next.After(c.now) {
+				w.next = w.next.Add(w.period)
+			}
+		}
+		active = append(active, w)
+	}
+	c.waiters = active
+}
+
+// Waiters returns the number of pending timers and tickers
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil waits until at least n timers or tickers are pending, so a test can
+// be sure the code under test is sleeping before it calls Advance
+func (c *FakeClock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		pending, changed := len(c.waiters), c.changed
+		c.mu.Unlock()
+		if pending >= n {
+			return
+		}
+		<-changed
+	}
+}
+
+func (c *FakeClock) addWaiter(d, period time.Duration) *fakeWaiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &fakeWaiter{next: c.now.Add(d), period: period, ch: make(chan time.Time, 1)}
+	if d <= 0 && period == 0 {
+		w.ch <- c.now
+		return w
+	}
+	c.waiters = append(c.waiters, w)
+	close(c.changed)
+	c.changed = make(chan struct{})
+	return w
+}
+
+type fakeTicker struct {
+	clock *FakeClock
+	w     *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.w.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.w.done = true
+}
@@ -0,0 +1,218 @@
+package logtest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Batch is a single insert request received by FakeVictoriaLogs
+type Batch struct {
+	Entries []map[string]interface{}
+	Header  http.Header
+	Query   url.Values
+	Raw     []byte
+}
+
+// FakeVictoriaLogs is an in-process stand-in for the VictoriaLogs insert API.
+// It records every accepted NDJSON batch and can be told to fail or stall requests.
+type FakeVictoriaLogs struct {
+	Server *httptest.Server
+
+	mu       sync.Mutex
+	batches  []Batch
+	requests int
+	failures []int
+	status   int
+	delay    time.Duration
+	notify   chan struct{}
+}
+
+// NewFakeVictoriaLogs starts a fake server that is shut down when the test ends
+func NewFakeVictoriaLogs(t testing.TB) *FakeVictoriaLogs {
+	t.Helper()
+	f := &FakeVictoriaLogs{
+		status: http.StatusOK,
+		notify: make(chan struct{}, 1),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/insert/jsonline", f.handleInsert)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Server.Close)
+	return f
+}
+
+// URL returns the base URL of the server
+func (f *FakeVictoriaLogs) URL() string {
+	return f.Server.URL
+}
+
+// InsertURL returns the jsonline insert endpoint, suitable for Config.VictoriaLogsURL
+func (f *FakeVictoriaLogs) InsertURL() string {
+	return f.Server.URL + "/insert/jsonline"
+}
+
+// FailNext makes the next n insert requests return status without recording the batch
+func (f *FakeVictoriaLogs) FailNext(n int, status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := 0; i < n; i++ {
+		f.failures = append(f.failures, status)
+	}
+}
+
+// RespondWith sets the status returned once queued failures are used up
+func (f *FakeVictoriaLogs) RespondWith(status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status = status
+}
+
+// SetDelay stalls every insert request for d, which lets tests trigger client timeouts
+func (f *FakeVictoriaLogs) SetDelay(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delay = d
+}
+
+// Requests returns the number of insert requests received, including failed ones
+func (f *FakeVictoriaLogs) Requests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+// Batches returns the accepted batches in arrival order
+func (f *FakeVictoriaLogs) Batches() []Batch {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]Batch, len(f.batches))
+	copy(out, f.batches)
+	return out
+}
+
+// Entries returns all accepted entries across batches
+func (f *FakeVictoriaLogs) Entries() []map[string]interface{} {
+	var out []map[string]interface{}
+	for _, batch := range f.Batches() {
+		out = append(out, batch.Entries...)
+	}
+	return out
+}
+
+// WaitForEntries blocks until at least n entries were accepted or timeout elapses
+func (f *FakeVictoriaLogs) WaitForEntries(n int, timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		if len(f.Entries()) >= n {
+			return true
+		}
+		select {
+		case <-f.notify:
+		case <-deadline.C:
+			return len(f.Entries()) >= n
+		}
+	}
+}
+
+// Reset forgets recorded batches and queued failures
+func (f *FakeVictoriaLogs) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = nil
+	f.failures = nil
+	f.requests = 0
+	f.status = http.StatusOK
+	f.delay = 0
+}
+
+func (f *FakeVictoriaLogs) handleInsert(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests++
+	delay := f.delay
+	status := f.status
+	if len(f.failures) > 0 {
+		status = f.failures[0]
+		f.failures = f.failures[1:]
+	}
+	f.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	raw, err := readBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if status >= 300 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	batch := Batch{Header: r.Header.Clone(), Query: r.URL.Query(), Raw: raw}
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			http.Error(w, fmt.Sprintf("cannot parse line %q: %v", line, err), http.StatusBadRequest)
+			return
+		}
+		batch.Entries = append(batch.Entries, entry)
+	}
+
+	f.mu.Lock()
+	f.batches = append(f.batches, batch)
+	f.mu.Unlock()
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+	w.WriteHeader(status)
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	var body io.Reader = r.Body
+	switch r.Header.Get("Content-Encoding") {
+	case "gzip":
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		body = gz
+	case "zstd":
+		zr, err := zstd.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		body = zr
+	}
+	return io.ReadAll(body)
+}
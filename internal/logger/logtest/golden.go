@@ -0,0 +1,72 @@
+package logtest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+)
+
+// FixedTime is the instant used by NewFixedClock and SampleEntries
+var FixedTime = time.Date(2024, 10, 21, 6, 30, 0, 0, time.UTC)
+
+// UpdateGoldenEnv rewrites golden files instead of comparing against them when set to 1
+const UpdateGoldenEnv = "UPDATE_GOLDEN"
+
+// NewFixedClock returns a FakeClock frozen at FixedTime
+func NewFixedClock() *FakeClock {
+	return NewFakeClock(FixedTime)
+}
+
+// SampleEntries returns a fixed set of entries covering every level, context ids and typical fields,
+// timestamped from clock one millisecond apart
+func SampleEntries(clock logger.Clock) []logger.LogEntry {
+	now := clock.Now()
+	at := func(i int) int64 {
+		return now.Add(time.Duration(i) * time.Millisecond).UnixNano()
+	}
+	return []logger.LogEntry{
+		{Level: logger.DEBUG, Message: "Health check requested", Timestamp: at(0), Service: "demo-api"},
+		{Level: logger.INFO, Message: "Create new User", Timestamp: at(1), Service: "demo-api",
+			TraceID: "trace_1", UserID: "user_1",
+			Fields: map[string]interface{}{"action": "create_user_success", "duration": 105, "username": "johndoe"}},
+		{Level: logger.WARN, Message: "This is a warning message", Timestamp: at(2), Service: "demo-api",
+			Fields: map[string]interface{}{"counter": 5, "nested": map[string]interface{}{"ok": true}}},
+		{Level: logger.ERROR, Message: "Failed to create user", Timestamp: at(3), Service: "demo-api",
+			TraceID: "trace_2", Fields: map[string]interface{}{"error_code": "DEMO_ERROR"}},
+		{Level: logger.FATAL, Message: "Failed to start server", Timestamp: at(4), Service: "demo-api"},
+	}
+}
+
+// AssertGolden encodes entries with enc and compares the output with the golden file at path.
+// Run the test with UPDATE_GOLDEN=1 to (re)write the file after an intended format change.
+func AssertGolden(t testing.TB, path string, enc logger.Encoder, entries []logger.LogEntry) {
+	t.Helper()
+	var got bytes.Buffer
+	for _, entry := range entries {
+		if err := enc.Encode(&got, entry); err != nil {
+			t.Fatalf("encode %q: %v", entry.Message, err)
+		}
+	}
+
+	if os.Getenv(UpdateGoldenEnv) == "1" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create golden dir: %v", err)
+		}
+		if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+			t.Fatalf("write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file (run with %s=1 to create it): %v", UpdateGoldenEnv, err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("encoded output differs from %s\n--- got\n%s\n--- want\n%s", path, got.Bytes(), want)
+	}
+}
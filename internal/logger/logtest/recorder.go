@@ -0,0 +1,68 @@
+// Package logtest provides helpers for testing code that logs through the logger package.
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+)
+
+// Recorder is a Logger that keeps every entry in memory so tests can assert on them.
+// Entries and Reset come from the embedded logger.CaptureLogger.
+type Recorder struct {
+	*logger.CaptureLogger
+}
+
+// NewRecorder returns an empty Recorder capturing all levels
+func NewRecorder() *Recorder {
+	return &Recorder{CaptureLogger: logger.NewCaptureLogger(logger.DEBUG)}
+}
+
+// Find returns the entries matching level, message substring and fields
+func (r *Recorder) Find(level logger.LogLevel, msgContains string, fieldMatch map[string]interface{}) []logger.LogEntry {
+	var found []logger.LogEntry
+	for _, entry := range r.Entries() {
+		if entry.Level == level && strings.Contains(entry.Message, msgContains) && fieldsMatch(entry.Fields, fieldMatch) {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+// AssertLogged fails the test unless an entry with the given level, a message containing msgContains
+// and all fields in fieldMatch (which may be nil) was recorded
+func (r *Recorder) AssertLogged(t testing.TB, level logger.LogLevel, msgContains string, fieldMatch map[string]interface{}) {
+	t.Helper()
+	if len(r.Find(level, msgContains, fieldMatch)) > 0 {
+		return
+	}
+	var b strings.Builder
+	for _, entry := range r.Entries() {
+		fmt.Fprintf(&b, "\n  %s %q %v", entry.Level, entry.Message, entry.Fields)
+	}
+	t.Errorf("no %s entry containing %q with fields %v was logged; recorded:%s", level, msgContains, fieldMatch, b.String())
+}
+
+// AssertNotLogged fails the test if a matching entry was recorded
+func (r *Recorder) AssertNotLogged(t testing.TB, level logger.LogLevel, msgContains string, fieldMatch map[string]interface{}) {
+	t.Helper()
+	if found := r.Find(level, msgContains, fieldMatch); len(found) > 0 {
+		t.Errorf("unexpected %s entry containing %q: %v", level, msgContains, found[0])
+	}
+}
+
+func fieldsMatch(fields, match map[string]interface{}) bool {
+	for k, want := range match {
+		got, ok := fields[k]
+		if !ok {
+			return false
+		}
+		if !reflect.DeepEqual(got, want) && fmt.Sprint(got) != fmt.Sprint(want) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package logtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+)
+
+// SyncConfig returns a config that delivers every entry in-process on the calling goroutine:
+// no buffer, no background worker and a single delivery attempt, so tests need no sleeps.
+// Fatal does not exit the test binary.
+func SyncConfig(url string) *logger.Config {
+	config := logger.DefaultConfig()
+	config.VictoriaLogsURL = url
+	config.ServiceName = "test"
+	config.Async = false
+	config.MaxRetries = 1
+	config.Timeout = 5 * time.Second
+	config.DisableFatalExit = true
+	return config
+}
+
+// NewSyncLogger builds a synchronous logger for url, applies opts to its config and closes it when the test ends
+func NewSyncLogger(t testing.TB, url string, opts ...func(*logger.Config)) *logger.VictoriaLogsLogger {
+	t.Helper()
+	config := SyncConfig(url)
+	for _, opt := range opts {
+		opt(config)
+	}
+	config.Async = false
+
+	l, err := logger.NewVictoriaLogsLogger(config)
+	if err != nil {
+		t.Fatalf("create logger: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+	return l
+}
+
+// NewLogger returns a synchronous logger pointed at the fake server, see NewSyncLogger
+func (f *FakeVictoriaLogs) NewLogger(t testing.TB, opts ...func(*logger.Config)) *logger.VictoriaLogsLogger {
+	t.Helper()
+	return NewSyncLogger(t, f.InsertURL(), opts...)
+}
@@ -0,0 +1,51 @@
+package logger
+
+import "context"
+
+type nopLogger struct{}
+
+// Nop returns a Logger that discards everything. Panic still panics so control flow matches the real logger.
+func Nop() Logger {
+	return nopLogger{}
+}
+
+func (nopLogger) Debug(context.Context, string, map[string]interface{}) {}
+func (nopLogger) Info(context.Context, string, map[string]interface{})  {}
+func (nopLogger) Warn(context.Context, string, map[string]interface{})  {}
+func (nopLogger) Error(context.Context, string, map[string]interface{}) {}
+func (nopLogger) Fatal(context.Context, string, map[string]interface{}) {}
+func (nopLogger) Debugf(context.Context, string, ...interface{})        {}
+func (nopLogger) Infof(context.Context, string, ...interface{})         {}
+func (nopLogger) Warnf(context.Context, string, ...interface{})         {}
+func (nopLogger) Errorf(context.Context, string, ...interface{})        {}
+func (nopLogger) Fatalf(context.Context, string, ...interface{})        {}
+func (nopLogger) Debugw(context.Context, string, ...interface{})        {}
+func (nopLogger) Infow(context.Context, string, ...interface{})         {}
+func (nopLogger) Warnw(context.Context, string, ...interface{})         {}
+func (nopLogger) Errorw(context.Context, string, ...interface{})        {}
+func (nopLogger) Fatalw(context.Context, string, ...interface{})        {}
+func (nopLogger) Enabled(LogLevel) bool                                 { return false }
+func (nopLogger) BatchLog(e []LogEntry) (int, error)                    { return len(e), nil }
+func (nopLogger) Flush(context.Context) error                           { return nil }
+func (nopLogger) Close() error                                          { return nil }
+
+func (nopLogger) Panic(_ context.Context, msg string, _ map[string]interface{}) {
+	panic(msg)
+}
+
+func (nopLogger) Panicf(_ context.Context, format string, args ...interface{}) {
+	msg, _ := formatMessage(format, args)
+	panic(msg)
+}
+
+func (nopLogger) Panicw(_ context.Context, msg string, _ ...interface{}) {
+	panic(msg)
+}
+
+func (nopLogger) Audit(context.Context, string, map[string]interface{}) error {
+	return nil
+}
+
+func (nopLogger) BatchLogContext(_ context.Context, entries []LogEntry) (int, error) {
+	return len(entries), nil
+}
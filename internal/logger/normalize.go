@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// normalizeFields returns a copy of fields with every value converted by normalizeValue
+func normalizeFields(fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(fields))
+	for k, val := range fields {
+		out[k] = normalizeValue(val)
+	}
+	return out
+}
+
+// normalizeValue converts values that encoding/json renders badly into their log form:
+// errors become their message, times RFC 3339 strings, durations milliseconds (float),
+// and TextMarshaler and Stringer values their text. Nested maps and slices are converted too.
+func normalizeValue(val interface{}) interface{} {
+	if isNilPointer(val) {
+		return nil
+	}
+	switch v := val.(type) {
+	case string, bool, int, int64, float64, json.Number:
+		return v
+	case error:
+		return v.Error()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case time.Duration:
+		return float64(v) / float64(time.Millisecond)
+	case map[string]interface{}:
+		return normalizeFields(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = normalizeValue(elem)
+		}
+		return out
+	case json.Marshaler:
+		return v
+	case encoding.TextMarshaler:
+		text, err := v.MarshalText()
+		if err != nil {
+			return fmt.Sprintf("!ERROR: %v", err)
+		}
+		return string(text)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return val
+}
+
+// isNilPointer reports whether val is a typed nil pointer, whose Error or String method would panic
+func isNilPointer(val interface{}) bool {
+	if val == nil {
+		return false
+	}
+	rv := reflect.ValueOf(val)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
@@ -0,0 +1,200 @@
+package logger
+
+import "time"
+
+// Option sets one Config field for New
+type Option func(*Config)
+
+// New creates a logger from DefaultConfig with opts applied in order, e.g.
+//
+//	log, err := logger.New(logger.WithURL("http://vl:9428/insert/jsonline"), logger.WithBatchSize(200))
+//
+// Sizes and durations left at zero by an option fall back to their defaults. Settings without
+// an option of their own can be changed with WithConfig.
+func New(opts ...Option) (*VictoriaLogsLogger, error) {
+	config := DefaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+	config.fillDefaults()
+	return NewVictoriaLogsLogger(config)
+}
+
+// fillDefaults replaces zero or negative sizes and durations with the DefaultConfig values
+func (c *Config) fillDefaults() {
+	d := DefaultConfig()
+	if c.VictoriaLogsURL == "" && len(c.Endpoints) == 0 {
+		c.VictoriaLogsURL = d.VictoriaLogsURL
+	}
+	if c.ServiceName == "" {
+		c.ServiceName = d.ServiceName
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = d.BatchSize
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = d.FlushInterval
+	}
+	if c.MaxRetries < 0 {
+		c.MaxRetries = d.MaxRetries
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = d.Timeout
+	}
+	if c.BufferSize <= 0 {
+		c.BufferSize = d.BufferSize
+	}
+}
+
+// WithURL sets the VictoriaLogs insert URL
+func WithURL(url string) Option {
+	return func(c *Config) { c.VictoriaLogsURL = url }
+}
+
+// WithEndpoints sends to several VictoriaLogs replicas, see Config.Endpoints
+func WithEndpoints(policy string, urls ...string) Option {
+	return func(c *Config) {
+		c.EndpointPolicy = policy
+		c.Endpoints = urls
+	}
+}
+
+// WithServiceName sets the service field of every entry
+func WithServiceName(name string) Option {
+	return func(c *Config) { c.ServiceName = name }
+}
+
+// WithBatchSize sets the number of entries per insert request
+func WithBatchSize(n int) Option {
+	return func(c *Config) { c.BatchSize = n }
+}
+
+// WithFlushInterval sets how often a partial batch is sent
+func WithFlushInterval(d time.Duration) Option {
+	return func(c *Config) { c.FlushInterval = d }
+}
+
+// WithMaxRetries sets the retry attempts per batch
+func WithMaxRetries(n int) Option {
+	return func(c *Config) { c.MaxRetries = n }
+}
+
+// WithRetryPolicy sets how failed requests are retried
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Config) { c.Retry = policy }
+}
+
+// WithTimeout sets the HTTP timeout of each request
+func WithTimeout(d time.Duration) Option {
+	return func(c *Config) { c.Timeout = d }
+}
+
+// WithBufferSize sets the capacity of the in-memory buffer
+func WithBufferSize(n int) Option {
+	return func(c *Config) { c.BufferSize = n }
+}
+
+// WithAsync turns buffered background delivery on or off
+func WithAsync(async bool) Option {
+	return func(c *Config) { c.Async = async }
+}
+
+// WithWorkers sets the number of concurrent senders in async mode
+func WithWorkers(n int) Option {
+	return func(c *Config) { c.Workers = n }
+}
+
+// WithMinLevel drops entries below level
+func WithMinLevel(level LogLevel) Option {
+	return func(c *Config) { c.MinLevel = level }
+}
+
+// WithFormat sets FormatJSON, FormatConsole or FormatStdout
+func WithFormat(format string) Option {
+	return func(c *Config) { c.Format = format }
+}
+
+// WithEnvironment selects the FieldPolicies entry that applies
+func WithEnvironment(env string) Option {
+	return func(c *Config) { c.Environment = env }
+}
+
+// WithCompression sets CompressionGzip, CompressionZstd or CompressionNone
+func WithCompression(compression string) Option {
+	return func(c *Config) { c.Compression = compression }
+}
+
+// WithBasicAuth authenticates requests with a username and password
+func WithBasicAuth(username, password string) Option {
+	return func(c *Config) { c.BasicAuth = &BasicAuth{Username: username, Password: password} }
+}
+
+// WithBearerToken authenticates requests with a bearer token
+func WithBearerToken(token string) Option {
+	return func(c *Config) { c.BearerToken = token }
+}
+
+// WithHeaders adds headers to every request, on top of those set before
+func WithHeaders(headers map[string]string) Option {
+	return func(c *Config) {
+		if c.Headers == nil {
+			c.Headers = make(map[string]string, len(headers))
+		}
+		for k, v := range headers {
+			c.Headers[k] = v
+		}
+	}
+}
+
+// WithStreamFields sets the fields identifying a log stream
+func WithStreamFields(fields ...string) Option {
+	return func(c *Config) { c.StreamFields = fields }
+}
+
+// WithQueue keeps buffered entries on disk in dir, see QueueConfig
+func WithQueue(dir string) Option {
+	return func(c *Config) { c.Queue.Dir = dir }
+}
+
+// WithDelivery sets DeliveryAtMostOnce or DeliveryAtLeastOnce
+func WithDelivery(mode string) Option {
+	return func(c *Config) { c.Delivery = mode }
+}
+
+// WithSinks adds sinks receiving every batch
+func WithSinks(sinks ...Sink) Option {
+	return func(c *Config) { c.Sinks = append(c.Sinks, sinks...) }
+}
+
+// WithFallbackSink sets the sink for batches VictoriaLogs did not accept
+func WithFallbackSink(sink Sink) Option {
+	return func(c *Config) { c.FallbackSink = sink }
+}
+
+// WithHooks adds hooks run on every entry
+func WithHooks(hooks ...Hook) Option {
+	return func(c *Config) { c.Hooks = append(c.Hooks, hooks...) }
+}
+
+// WithOnDrop sets the callback for entries that will not reach VictoriaLogs
+func WithOnDrop(fn func(entries []LogEntry, reason error)) Option {
+	return func(c *Config) { c.OnDrop = fn }
+}
+
+// WithBatchListeners sets the callbacks run after each insert request is accepted or given up on
+func WithBatchListeners(sent func(n int, bytes int, dur time.Duration), failed func(n int, err error)) Option {
+	return func(c *Config) {
+		c.OnBatchSent = sent
+		c.OnBatchFailed = failed
+	}
+}
+
+// WithErrorHandler sets the callback for internal errors
+func WithErrorHandler(fn func(err error)) Option {
+	return func(c *Config) { c.ErrorHandler = fn }
+}
+
+// WithConfig changes any other Config field
+func WithConfig(fn func(*Config)) Option {
+	return Option(fn)
+}
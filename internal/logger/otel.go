@@ -0,0 +1,22 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanIDKey is the field holding the OpenTelemetry span ID
+const SpanIDKey = "span_id"
+
+// OTelExtractor fills trace_id and span_id from the OpenTelemetry span active in the context
+func OTelExtractor(ctx context.Context) map[string]interface{} {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return map[string]interface{}{
+		TraceIDKey: sc.TraceID().String(),
+		SpanIDKey:  sc.SpanID().String(),
+	}
+}
@@ -0,0 +1,230 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// otlpScope is the instrumentation scope reported for exported records
+const otlpScope = "github.com/anhdnyopaz/go_victorialog"
+
+// OTLPSink exports entries as OTLP/HTTP protobuf log records, e.g. to an OpenTelemetry Collector.
+// Fields become attributes, the service becomes the service.name resource attribute, and
+// trace_id/span_id are set on the record when they are valid hex IDs. Add it to Config.Sinks
+// to export alongside VictoriaLogs, or set it as Config.Output to export instead.
+type OTLPSink struct {
+	// Client sends the export requests; NewOTLPSink sets a client with a 30s timeout
+	Client *http.Client
+	// Headers are added to every request, e.g. an API key for a hosted collector
+	Headers map[string]string
+
+	endpoint string
+}
+
+// NewOTLPSink returns a sink posting to endpoint, the full logs URL such as http://collector:4318/v1/logs
+func NewOTLPSink(endpoint string) (*OTLPSink, error) {
+	if _, err := url.Parse(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint: %w", err)
+	}
+	return &OTLPSink{
+		Client:   &http.Client{Timeout: 30 * time.Second},
+		endpoint: endpoint,
+	}, nil
+}
+
+func (s *OTLPSink) Write(ctx context.Context, entries []LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	data, err := proto.Marshal(otlpRequest(entries))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for name, value := range s.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("OTLP endpoint returned status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close is a no-op: requests are synchronous
+func (s *OTLPSink) Close() error {
+	return nil
+}
+
+// otlpRequest groups entries into one ResourceLogs per service
+func otlpRequest(entries []LogEntry) *collectorpb.ExportLogsServiceRequest {
+	req := &collectorpb.ExportLogsServiceRequest{}
+	byService := make(map[string]*logspb.ScopeLogs)
+	for _, entry := range entries {
+		scope, ok := byService[entry.Service]
+		if !ok {
+			scope = &logspb.ScopeLogs{Scope: &commonpb.InstrumentationScope{Name: otlpScope}}
+			byService[entry.Service] = scope
+			resource := &resourcepb.Resource{}
+			if entry.Service != "" {
+				resource.Attributes = []*commonpb.KeyValue{otlpKeyValue("service.name", entry.Service)}
+			}
+			req.ResourceLogs = append(req.ResourceLogs, &logspb.ResourceLogs{
+				Resource:  resource,
+				ScopeLogs: []*logspb.ScopeLogs{scope},
+			})
+		}
+		scope.LogRecords = append(scope.LogRecords, otlpRecord(entry))
+	}
+	return req
+}
+
+func otlpRecord(entry LogEntry) *logspb.LogRecord {
+	record := &logspb.LogRecord{
+		TimeUnixNano:         uint64(entry.Timestamp),
+		ObservedTimeUnixNano: uint64(entry.Timestamp),
+		SeverityNumber:       otlpSeverity(entry.Level),
+		SeverityText:         entry.Level.String(),
+		Body:                 otlpValue(entry.Message),
+	}
+
+	fields := normalizeFields(entry.Fields)
+	if fields == nil {
+		fields = make(map[string]interface{}, 3)
+	}
+	if entry.UserID != "" {
+		fields[UserIDKey] = entry.UserID
+	}
+	if entry.Stream != "" {
+		fields[StreamKey] = entry.Stream
+	}
+	if id, ok := decodeID(entry.TraceID, 16); ok {
+		record.TraceId = id
+	} else if entry.TraceID != "" {
+		fields[TraceIDKey] = entry.TraceID
+	}
+	if spanID, _ := fields[SpanIDKey].(string); spanID != "" {
+		if id, ok := decodeID(spanID, 8); ok {
+			record.SpanId = id
+			delete(fields, SpanIDKey)
+		}
+	}
+	record.Attributes = otlpAttributes(fields)
+	return record
+}
+
+// decodeID decodes a hex trace or span ID of the given byte length
+func decodeID(s string, size int) ([]byte, bool) {
+	if len(s) != size*2 {
+		return nil, false
+	}
+	id, err := hex.DecodeString(s)
+	return id, err == nil
+}
+
+func otlpSeverity(level LogLevel) logspb.SeverityNumber {
+	switch level {
+	case DEBUG:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG
+	case INFO:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+	case WARN:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_WARN
+	case ERROR:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+	case PANIC:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL
+	case FATAL:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL4
+	}
+	return logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED
+}
+
+// otlpAttributes converts fields to attributes sorted by key, so output is deterministic
+func otlpAttributes(fields map[string]interface{}) []*commonpb.KeyValue {
+	if len(fields) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, otlpKeyValue(k, fields[k]))
+	}
+	return attrs
+}
+
+func otlpKeyValue(key string, val interface{}) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: otlpValue(val)}
+}
+
+// otlpValue maps a normalized field value to an AnyValue; unknown types are stringified
+func otlpValue(val interface{}) *commonpb.AnyValue {
+	switch v := val.(type) {
+	case nil:
+		return &commonpb.AnyValue{}
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}
+	case int:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int8:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int16:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v}}
+	case uint8:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case uint16:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case uint32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case float32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: float64(v)}}
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v}}
+	case []byte:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: v}}
+	case []interface{}:
+		values := make([]*commonpb.AnyValue, len(v))
+		for i, elem := range v {
+			values[i] = otlpValue(elem)
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}}
+	case map[string]interface{}:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: otlpAttributes(v)}}}
+	}
+	if nested, ok := nestedFields(val); ok {
+		return otlpValue(nested)
+	}
+	return otlpValue(fmt.Sprint(val))
+}
@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// FieldAction tells the logger what to do with a sensitive field
+type FieldAction string
+
+const (
+	FieldKeep FieldAction = "keep"
+	FieldDrop FieldAction = "drop"
+	FieldHash FieldAction = "hash"
+)
+
+// FieldPolicy maps field names to the action applied before an entry leaves the process
+type FieldPolicy map[string]FieldAction
+
+// fieldPolicy returns the policy configured for the current environment, if any
+func (c *Config) fieldPolicy() FieldPolicy {
+	if c == nil || len(c.FieldPolicies) == 0 {
+		return nil
+	}
+	return c.FieldPolicies[c.Environment]
+}
+
+// Validate rejects actions other than FieldKeep, FieldDrop and FieldHash, which Apply would
+// silently treat as keep
+func (p FieldPolicy) Validate() error {
+	for name, action := range p {
+		switch action {
+		case FieldKeep, FieldDrop, FieldHash:
+		default:
+			return fmt.Errorf("unknown action %q for field %q, want keep, drop or hash", action, name)
+		}
+	}
+	return nil
+}
+
+// validateFieldPolicies checks the policy of every environment, not only the current one
+func (c *Config) validateFieldPolicies() error {
+	for env, p := range c.FieldPolicies {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("field policy for environment %q: %w", env, err)
+		}
+	}
+	return nil
+}
+
+// Apply rewrites fields in place according to the policy
+func (p FieldPolicy) Apply(fields map[string]interface{}) {
+	if len(p) == 0 || len(fields) == 0 {
+		return
+	}
+	for name, action := range p {
+		value, ok := fields[name]
+		if !ok {
+			continue
+		}
+		switch action {
+		case FieldDrop:
+			delete(fields, name)
+		case FieldHash:
+			fields[name] = hashValue(value)
+		}
+	}
+}
+
+// applyEntry applies the policy to the fields of entry and to its TraceID and UserID, which
+// are matched by TraceIDKey and UserIDKey
+func (p FieldPolicy) applyEntry(entry *LogEntry) {
+	if len(p) == 0 {
+		return
+	}
+	p.Apply(entry.Fields)
+	entry.TraceID = p.applyID(TraceIDKey, entry.TraceID)
+	entry.UserID = p.applyID(UserIDKey, entry.UserID)
+}
+
+func (p FieldPolicy) applyID(name, value string) string {
+	if value == "" {
+		return value
+	}
+	switch p[name] {
+	case FieldDrop:
+		return ""
+	case FieldHash:
+		return hashValue(value)
+	}
+	return value
+}
+
+func hashValue(value interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprint(value)))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,69 @@
+package logger_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+	"github.com/anhdnyopaz/go_victorialog/internal/logger/logtest"
+)
+
+func TestFieldPolicyCoversContextAndHooks(t *testing.T) {
+	fake := logtest.NewFakeVictoriaLogs(t)
+	l := fake.NewLogger(t, func(c *logger.Config) {
+		c.Environment = "production"
+		c.FieldPolicies = map[string]logger.FieldPolicy{"production": {
+			"session_id": logger.FieldDrop,
+			"email":      logger.FieldHash,
+			"user_id":    logger.FieldHash,
+		}}
+		c.ContextExtractors = append(logger.DefaultContextExtractors(), func(ctx context.Context) map[string]interface{} {
+			return map[string]interface{}{"session_id": "s3cr3t"}
+		})
+		c.Hooks = []logger.Hook{func(entry *logger.LogEntry) error {
+			entry.Fields["email"] = "john@example.com"
+			return nil
+		}}
+	})
+
+	ctx := logger.ContextWithUserID(context.Background(), "user_1")
+	l.Info(ctx, "Signed in", nil)
+
+	entries := fake.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	fields, _ := entry["fields"].(map[string]interface{})
+	if _, ok := fields["session_id"]; ok {
+		t.Errorf("session_id from the context was not dropped: %v", fields)
+	}
+	if email, _ := fields["email"].(string); !strings.HasPrefix(email, "sha256:") {
+		t.Errorf("email added by a hook was not hashed: %v", fields["email"])
+	}
+	if userID, _ := entry["user_id"].(string); !strings.HasPrefix(userID, "sha256:") {
+		t.Errorf("user_id from the context was not hashed: %v", entry["user_id"])
+	}
+}
+
+func TestFieldPolicyRejectsUnknownAction(t *testing.T) {
+	config := logtest.SyncConfig("http://localhost:9428/insert/jsonline")
+	config.FieldPolicies = map[string]logger.FieldPolicy{"staging": {"password": "Drop"}}
+	if _, err := logger.NewVictoriaLogsLogger(config); err == nil {
+		t.Error("NewVictoriaLogsLogger accepted the action Drop")
+	}
+
+	yaml := "field_policies:\n  production:\n    email: hashed\n"
+	if _, err := logger.ParseConfig([]byte(yaml), false); err == nil {
+		t.Error("ParseConfig accepted the action hashed")
+	}
+
+	fake := logtest.NewFakeVictoriaLogs(t)
+	l := fake.NewLogger(t)
+	reload := logtest.SyncConfig(fake.InsertURL())
+	reload.FieldPolicies = map[string]logger.FieldPolicy{"production": {"token": "redact"}}
+	if err := l.Reload(reload); err == nil {
+		t.Error("Reload accepted the action redact")
+	}
+}
@@ -0,0 +1,440 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrQueueFull is returned when an entry would take the disk queue past QueueConfig.MaxSize
+var ErrQueueFull = errors.New("logger: disk queue full")
+
+// QueueConfig enables a write-ahead queue on disk for buffered entries. Entries are appended
+// to segment files in Dir before delivery and removed once handed off to VictoriaLogs or the
+// fallback sink, so entries not sent yet survive a crash or restart and are sent when the
+// logger starts again. While VictoriaLogs is slow, entries wait on disk rather than being
+// dropped from the in-memory buffer, up to MaxSize bytes (default 1 GiB). Segment files are
+// rotated at SegmentSize (default 64 MiB), capped at a quarter of MaxSize so that handed off
+// segments can be removed before the queue fills. The queue requires Async.
+type QueueConfig struct {
+	Dir         string `yaml:"dir"`
+	MaxSize     int64  `yaml:"max_size"`
+	SegmentSize int64  `yaml:"segment_size"`
+}
+
+const (
+	defaultQueueMaxSize     = 1 << 30
+	defaultQueueSegmentSize = 64 << 20
+
+	queueSegmentExt = ".wal"
+	// queueAckFile holds the sequence number of the oldest entry not handed off yet
+	queueAckFile = "ack"
+)
+
+// queueRecord is one line of a segment file. Tenant is stored beside the entry because
+// LogEntry does not serialize it.
+type queueRecord struct {
+	Seq    uint64   `json:"seq"`
+	Tenant Tenant   `json:"tenant"`
+	Entry  LogEntry `json:"entry"`
+}
+
+type queueSegment struct {
+	first uint64
+	path  string
+	size  int64
+}
+
+// diskQueue is an append-only log of entries split into segments named after the sequence
+// number of their first entry. Entries are read in order by next and acknowledged with ack,
+// possibly out of order; a segment is removed once every entry in it is acknowledged.
+type diskQueue struct {
+	mu          sync.Mutex
+	dir         string
+	maxSize     int64
+	segmentSize int64
+	segments    []*queueSegment
+	size        int64
+	nextSeq     uint64
+	// acked is the oldest sequence number not acknowledged; done holds later acknowledged ones
+	acked  uint64
+	done   map[uint64]struct{}
+	writer *os.File
+	// reader is positioned in the segment starting at readFirst
+	reader    *bufio.Reader
+	readFile  *os.File
+	readFirst uint64
+	// notify wakes up a reader waiting in next after an append
+	notify chan struct{}
+	// onError receives read and cleanup errors, see Config.ErrorHandler
+	onError func(error)
+}
+
+func openDiskQueue(c QueueConfig, onError func(error)) (*diskQueue, error) {
+	q := &diskQueue{
+		onError:     onError,
+		dir:         c.Dir,
+		maxSize:     c.MaxSize,
+		segmentSize: c.SegmentSize,
+		done:        make(map[uint64]struct{}),
+		notify:      make(chan struct{}, 1),
+		nextSeq:     1,
+		acked:       1,
+	}
+	if q.maxSize <= 0 {
+		q.maxSize = defaultQueueMaxSize
+	}
+	if q.segmentSize <= 0 {
+		q.segmentSize = defaultQueueSegmentSize
+	}
+	q.segmentSize = max(min(q.segmentSize, q.maxSize/4), 1)
+	if err := os.MkdirAll(q.dir, 0o755); err != nil {
+		return nil, err
+	}
+	if data, err := os.ReadFile(filepath.Join(q.dir, queueAckFile)); err == nil {
+		if acked, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err == nil && acked > 0 {
+			q.acked = acked
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := q.loadSegments(); err != nil {
+		return nil, err
+	}
+	q.nextSeq = max(q.nextSeq, q.acked)
+	q.removeAcked()
+
+	if len(q.segments) == 0 {
+		if err := q.rotate(); err != nil {
+			return nil, err
+		}
+	} else {
+		last := q.segments[len(q.segments)-1]
+		f, err := os.OpenFile(last.path, os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		q.writer = f
+	}
+	q.readFirst = q.segments[0].first
+	return q, nil
+}
+
+// loadSegments lists the segment files and finds the next sequence number in the last one,
+// cutting off a line torn by a crash during append
+func (q *diskQueue) loadSegments() error {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, queueSegmentExt) {
+			continue
+		}
+		first, err := strconv.ParseUint(strings.TrimSuffix(name, queueSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return err
+		}
+		q.segments = append(q.segments, &queueSegment{first: first, path: filepath.Join(q.dir, name), size: info.Size()})
+	}
+	slices.SortFunc(q.segments, func(a, b *queueSegment) int {
+		return compareUint64(a.first, b.first)
+	})
+	if len(q.segments) == 0 {
+		return nil
+	}
+
+	last := q.segments[len(q.segments)-1]
+	data, err := os.ReadFile(last.path)
+	if err != nil {
+		return err
+	}
+	q.nextSeq = last.first
+	var valid int64
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		var rec queueRecord
+		if err := json.Unmarshal(data[:i], &rec); err != nil {
+			break
+		}
+		q.nextSeq = rec.Seq + 1
+		valid += int64(i + 1)
+		data = data[i+1:]
+	}
+	if valid < last.size {
+		if err := os.Truncate(last.path, valid); err != nil {
+			return err
+		}
+		last.size = valid
+	}
+	for _, s := range q.segments {
+		q.size += s.size
+	}
+	return nil
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// rotate starts a new segment for the entries from nextSeq on
+func (q *diskQueue) rotate() error {
+	path := filepath.Join(q.dir, fmt.Sprintf("%020d%s", q.nextSeq, queueSegmentExt))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if q.writer != nil {
+		_ = q.writer.Close()
+	}
+	q.writer = f
+	q.segments = append(q.segments, &queueSegment{first: q.nextSeq, path: path})
+	return nil
+}
+
+// append writes entry at the end of the queue
+func (q *diskQueue) append(entry LogEntry) error {
+	entry.Fields = normalizeFields(entry.Fields)
+	// The record is encoded without its sequence number, which is spliced in under the lock
+	body, err := json.Marshal(queueRecord{Tenant: entry.Tenant, Entry: entry})
+	if err != nil {
+		return err
+	}
+	body = bytes.TrimPrefix(body, []byte(`{"seq":0,`))
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	line := make([]byte, 0, len(body)+32)
+	line = append(line, `{"seq":`...)
+	line = strconv.AppendUint(line, q.nextSeq, 10)
+	line = append(line, ',')
+	line = append(line, body...)
+	line = append(line, '\n')
+	current := q.segments[len(q.segments)-1]
+	if current.size > 0 && q.acked == q.nextSeq {
+		// Everything written was handed off: start a new segment so the current one can go
+		if err := q.rotate(); err != nil {
+			return err
+		}
+		q.removeAcked()
+		current = q.segments[len(q.segments)-1]
+	}
+	if q.size+int64(len(line)) > q.maxSize {
+		return ErrQueueFull
+	}
+	if current.size > 0 && current.size+int64(len(line)) > q.segmentSize {
+		if err := q.rotate(); err != nil {
+			return err
+		}
+		current = q.segments[len(q.segments)-1]
+	}
+	if _, err := q.writer.Write(line); err != nil {
+		return err
+	}
+	current.size += int64(len(line))
+	q.size += int64(len(line))
+	q.nextSeq++
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// next returns the oldest entry not read yet, waiting for one to be appended until done is closed
+func (q *diskQueue) next(done <-chan struct{}) (LogEntry, bool) {
+	for {
+		q.mu.Lock()
+		entry, ok := q.read()
+		q.mu.Unlock()
+		if ok {
+			return entry, true
+		}
+		select {
+		case <-q.notify:
+		case <-done:
+			return LogEntry{}, false
+		}
+	}
+}
+
+func (q *diskQueue) read() (LogEntry, bool) {
+	for {
+		if q.reader == nil {
+			seg := q.segmentAt(q.readFirst)
+			if seg == nil {
+				return LogEntry{}, false
+			}
+			f, err := os.Open(seg.path)
+			if err != nil {
+				q.onError(fmt.Errorf("queue: %w", err))
+				return LogEntry{}, false
+			}
+			q.readFile, q.reader = f, bufio.NewReader(f)
+		}
+		line, err := q.reader.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			nextSeg := q.segmentAfter(q.readFirst)
+			if nextSeg == nil {
+				return LogEntry{}, false
+			}
+			_ = q.readFile.Close()
+			q.readFile, q.reader, q.readFirst = nil, nil, nextSeg.first
+			continue
+		}
+		if err != nil && err != io.EOF {
+			q.onError(fmt.Errorf("queue: %w", err))
+			return LogEntry{}, false
+		}
+		var rec queueRecord
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.UseNumber()
+		if err := dec.Decode(&rec); err != nil {
+			q.onError(fmt.Errorf("queue: skipping corrupt record: %w", err))
+			continue
+		}
+		if rec.Seq < q.acked {
+			continue
+		}
+		if _, ok := q.done[rec.Seq]; ok {
+			continue
+		}
+		entry := rec.Entry
+		entry.Tenant = rec.Tenant
+		entry.seq = rec.Seq
+		return entry, true
+	}
+}
+
+// segmentAt returns the segment starting at first, or the oldest one left if it was removed
+func (q *diskQueue) segmentAt(first uint64) *queueSegment {
+	for _, s := range q.segments {
+		if s.first >= first {
+			return s
+		}
+	}
+	return nil
+}
+
+func (q *diskQueue) segmentAfter(first uint64) *queueSegment {
+	for _, s := range q.segments {
+		if s.first > first {
+			return s
+		}
+	}
+	return nil
+}
+
+// appended returns the sequence number of the newest entry, zero when nothing was appended
+func (q *diskQueue) appended() uint64 {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.nextSeq - 1
+}
+
+// pending returns the number of entries not handed off yet
+func (q *diskQueue) pending() uint64 {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.nextSeq - q.acked - uint64(len(q.done))
+}
+
+// ack marks entries read from the queue as handed off; entries not from the queue are ignored
+func (q *diskQueue) ack(entries []LogEntry) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	before := q.acked
+	for _, e := range entries {
+		if e.seq >= q.acked {
+			q.done[e.seq] = struct{}{}
+		}
+	}
+	for {
+		if _, ok := q.done[q.acked]; !ok {
+			break
+		}
+		delete(q.done, q.acked)
+		q.acked++
+	}
+	if q.acked == before {
+		return
+	}
+	if err := q.writeAck(); err != nil {
+		q.onError(fmt.Errorf("queue: %w", err))
+	}
+	q.removeAcked()
+}
+
+// writeAck saves acked through a temporary file, so that a crash never leaves a torn ack file
+func (q *diskQueue) writeAck() error {
+	path := filepath.Join(q.dir, queueAckFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(q.acked, 10)), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// removeAcked deletes segments whose entries are all acknowledged, except the one being written
+func (q *diskQueue) removeAcked() {
+	for len(q.segments) > 1 && q.segments[1].first <= q.acked {
+		s := q.segments[0]
+		if q.reader != nil && q.readFirst == s.first {
+			// Every entry in s was acknowledged, so the reader is done with it
+			_ = q.readFile.Close()
+			q.readFile, q.reader, q.readFirst = nil, nil, q.segments[1].first
+		}
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			q.onError(fmt.Errorf("queue: %w", err))
+			break
+		}
+		q.size -= s.size
+		q.segments = q.segments[1:]
+	}
+}
+
+func (q *diskQueue) close() error {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.readFile != nil {
+		_ = q.readFile.Close()
+		q.readFile, q.reader = nil, nil
+	}
+	return q.writer.Close()
+}
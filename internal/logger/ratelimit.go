@@ -0,0 +1,154 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate is a token bucket: PerSecond tokens are added each second, up to Burst
+type Rate struct {
+	PerSecond float64 `yaml:"per_second"`
+	Burst     int     `yaml:"burst"`
+}
+
+// RateLimitConfig limits how many entries per second reach the buffer, globally and per level.
+// PANIC and FATAL entries are never limited. Suppressed entries are counted and reported in a
+// WARN entry every ReportInterval.
+type RateLimitConfig struct {
+	Global         Rate              `yaml:"global"`
+	PerLevel       map[LogLevel]Rate `yaml:"per_level"`
+	ReportInterval time.Duration     `yaml:"report_interval"`
+}
+
+const defaultRateLimitReportInterval = 10 * time.Second
+
+func (c RateLimitConfig) enabled() bool {
+	if c.Global.PerSecond > 0 {
+		return true
+	}
+	for _, rate := range c.PerLevel {
+		if rate.PerSecond > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate Rate, now time.Time) *tokenBucket {
+	burst := float64(rate.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate.PerSecond, burst: burst, tokens: burst, last: now}
+}
+
+func (b *tokenBucket) allow(now time.Time) bool {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateLimiter is shared by a logger and its children
+type rateLimiter struct {
+	mu         sync.Mutex
+	global     *tokenBucket
+	perLevel   map[LogLevel]*tokenBucket
+	suppressed map[LogLevel]int64
+	interval   time.Duration
+	lastReport time.Time
+}
+
+func newRateLimiter(config RateLimitConfig, now time.Time) *rateLimiter {
+	if !config.enabled() {
+		return nil
+	}
+	l := &rateLimiter{
+		perLevel:   make(map[LogLevel]*tokenBucket),
+		suppressed: make(map[LogLevel]int64),
+		interval:   config.ReportInterval,
+		lastReport: now,
+	}
+	if l.interval <= 0 {
+		l.interval = defaultRateLimitReportInterval
+	}
+	if config.Global.PerSecond > 0 {
+		l.global = newTokenBucket(config.Global, now)
+	}
+	for level, rate := range config.PerLevel {
+		if rate.PerSecond > 0 {
+			l.perLevel[level] = newTokenBucket(rate, now)
+		}
+	}
+	return l
+}
+
+// allow takes a token for level, counting the entry as suppressed when none is available
+func (l *rateLimiter) allow(level LogLevel, now time.Time) bool {
+	if level >= PANIC {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if bucket, ok := l.perLevel[level]; ok && !bucket.allow(now) {
+		l.suppressed[level]++
+		return false
+	}
+	if l.global != nil && !l.global.allow(now) {
+		l.suppressed[level]++
+		return false
+	}
+	return true
+}
+
+// takeReport returns the suppressed counts once per interval, resetting them
+func (l *rateLimiter) takeReport(now time.Time) map[LogLevel]int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastReport) < l.interval || len(l.suppressed) == 0 {
+		return nil
+	}
+	report := l.suppressed
+	l.suppressed = make(map[LogLevel]int64)
+	l.lastReport = now
+	return report
+}
+
+// reportSuppressed logs how many entries the rate limiter dropped since the last report.
+// The report itself bypasses the limiter.
+func (v *VictoriaLogsLogger) reportSuppressed() {
+	limiter := v.settings.Load().limiter
+	if limiter == nil {
+		return
+	}
+	report := limiter.takeReport(v.cfg().clock().Now())
+	if report == nil {
+		return
+	}
+	fields := make(map[string]interface{}, len(report)+1)
+	var total int64
+	for level, n := range report {
+		fields["suppressed_"+strings.ToLower(level.String())] = n
+		total += n
+	}
+	fields["suppressed_total"] = total
+
+	entry := v.createLogEntry(WARN, "Log entries suppressed by rate limiter", fields)
+	v.enqueue(entry)
+}
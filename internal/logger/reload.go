@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"syscall"
+)
+
+// loggerSettings is the config in effect and the state built from it
+type loggerSettings struct {
+	config      *Config
+	insertRoute route
+	auditRoute  route
+	// limiter is nil unless Config.RateLimit is enabled
+	limiter *rateLimiter
+	// sampler is nil unless Config.Sampling samples a level
+	sampler *levelSampler
+}
+
+func (v *VictoriaLogsLogger) cfg() *Config {
+	return v.settings.Load().config
+}
+
+// Reload switches the logger and every logger derived from it to config without losing
+// buffered entries. The level is reset to MinLevel, and endpoints, batching, flush interval,
+// retries, rate limits, Sampling, compression, auth, headers and field options take effect
+// for the next entry or batch. Endpoint health and the circuit breaker start over only when the
+// endpoints change. Fields fixed when the pipeline was built are kept from the current
+// config: Async, BufferSize, PriorityBufferSize, Workers, Queue, Delivery, Format,
+// HealthCheckInterval, DedupWindow, Sinks, Output, FallbackSink, Hooks, the HTTP client and TLS
+// settings, Clock, IDGenerator and ExpvarPrefix, as well as ServiceName and Tenant of
+// existing loggers. Samplers added with WithSampler are hooks and stay as built.
+func (v *VictoriaLogsLogger) Reload(config *Config) error {
+	if config == nil {
+		return errors.New("logger: nil config")
+	}
+	if v.life.closed.Load() {
+		return ErrClosed
+	}
+	v.life.reloadMu.Lock()
+	defer v.life.reloadMu.Unlock()
+
+	current := v.settings.Load()
+	next := *config
+	next.keepFixed(current.config)
+	insertRoute, auditRoute, err := next.newRoutes()
+	if err != nil {
+		return err
+	}
+	if err := validateCompression(next.Compression); err != nil {
+		return err
+	}
+	if err := next.validateAuth(); err != nil {
+		return err
+	}
+	if err := next.validateFieldPolicies(); err != nil {
+		return err
+	}
+	if err := next.validateDelivery(); err != nil {
+		return err
+	}
+
+	settings := &loggerSettings{
+		config:      &next,
+		insertRoute: insertRoute,
+		auditRoute:  auditRoute,
+		limiter:     current.limiter,
+		sampler:     current.sampler,
+	}
+	if !routesChanged(current.config, &next) {
+		// Keep endpoint health and breaker state; stream fields may still differ
+		settings.insertRoute.endpoints, settings.insertRoute.breaker = current.insertRoute.endpoints, current.insertRoute.breaker
+		settings.auditRoute.endpoints, settings.auditRoute.breaker = current.auditRoute.endpoints, current.auditRoute.breaker
+	}
+	if !reflect.DeepEqual(current.config.RateLimit, next.RateLimit) {
+		v.reportSuppressed()
+		settings.limiter = newRateLimiter(next.RateLimit, next.clock().Now())
+	}
+	if !reflect.DeepEqual(current.config.Sampling, next.Sampling) {
+		settings.sampler = newLevelSampler(next.Sampling)
+	}
+	v.settings.Store(settings)
+	v.SetLevel(next.MinLevel)
+
+	select {
+	case v.life.reloaded <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// keepFixed copies the fields Reload cannot change from the config in effect
+func (c *Config) keepFixed(current *Config) {
+	c.Async = current.Async
+	c.BufferSize = current.BufferSize
+	c.PriorityBufferSize = current.PriorityBufferSize
+	c.Workers = current.Workers
+	c.Queue = current.Queue
+	c.Delivery = current.Delivery
+	c.Format = current.Format
+	c.HealthCheckInterval = current.HealthCheckInterval
+	c.DedupWindow = current.DedupWindow
+	c.Sinks = current.Sinks
+	c.Output = current.Output
+	c.FallbackSink = current.FallbackSink
+	c.Hooks = current.Hooks
+	c.HTTPClient = current.HTTPClient
+	c.Transport = current.Transport
+	c.ProxyURL = current.ProxyURL
+	c.TLSConfig = current.TLSConfig
+	c.TLS = current.TLS
+	c.Clock = current.Clock
+	c.IDGenerator = current.IDGenerator
+	c.ExpvarPrefix = current.ExpvarPrefix
+	c.ServiceName = current.ServiceName
+	c.Tenant = current.Tenant
+}
+
+// routesChanged reports whether the endpoints or their circuit breaker differ between a and b
+func routesChanged(a, b *Config) bool {
+	return !slices.Equal(a.endpointURLs(), b.endpointURLs()) ||
+		a.EndpointPolicy != b.EndpointPolicy ||
+		a.EndpointCooldown != b.EndpointCooldown ||
+		a.AuditURL != b.AuditURL ||
+		a.CircuitBreaker != b.CircuitBreaker
+}
+
+// ReloadOnSignal calls Reload with LoadConfig(path) whenever one of sigs arrives (SIGHUP when
+// none are given), until stop is called. A config that fails to load is reported to
+// Config.ErrorHandler and the current one is kept.
+func (v *VictoriaLogsLogger) ReloadOnSignal(path string, sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+			case <-done:
+				return
+			case <-v.life.ctx.Done():
+				signal.Stop(ch)
+				return
+			}
+			config, err := LoadConfig(path)
+			if err == nil {
+				err = v.Reload(config)
+			}
+			if err != nil {
+				v.reportError(fmt.Errorf("reload %s: %w", path, err))
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
@@ -0,0 +1,28 @@
+package logger
+
+// levelSampler keeps one in n entries per level, see Config.Sampling
+type levelSampler struct {
+	levels map[LogLevel]*everyN
+}
+
+// newLevelSampler returns nil when no level is sampled
+func newLevelSampler(rates map[LogLevel]int) *levelSampler {
+	s := &levelSampler{levels: make(map[LogLevel]*everyN, len(rates))}
+	for level, n := range rates {
+		if n > 1 && level < PANIC {
+			s.levels[level] = &everyN{n: uint64(n)}
+		}
+	}
+	if len(s.levels) == 0 {
+		return nil
+	}
+	return s
+}
+
+func (s *levelSampler) sample(level LogLevel) bool {
+	counter, ok := s.levels[level]
+	if !ok {
+		return true
+	}
+	return (counter.count.Add(1)-1)%counter.n == 0
+}
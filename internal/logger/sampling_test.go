@@ -0,0 +1,35 @@
+package logger_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+	"github.com/anhdnyopaz/go_victorialog/internal/logger/logtest"
+)
+
+func TestSamplingReload(t *testing.T) {
+	fake := logtest.NewFakeVictoriaLogs(t)
+	l := fake.NewLogger(t, func(c *logger.Config) {
+		c.Sampling = map[logger.LogLevel]int{logger.DEBUG: 10}
+	})
+	for i := 0; i < 100; i++ {
+		l.Debug(context.Background(), "Cache lookup", nil)
+		l.Error(context.Background(), "Cache unavailable", nil)
+	}
+	if n := len(fake.Entries()); n != 110 {
+		t.Fatalf("got %d entries, want 10 DEBUG and 100 ERROR", n)
+	}
+
+	fake.Reset()
+	config := logtest.SyncConfig(fake.InsertURL())
+	if err := l.Reload(config); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		l.Debug(context.Background(), "Cache lookup", nil)
+	}
+	if n := len(fake.Entries()); n != 100 {
+		t.Errorf("got %d entries after disabling sampling, want 100", n)
+	}
+}
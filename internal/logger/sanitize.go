@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMaxInputLength is the length user-supplied values are truncated to by Sanitize
+const DefaultMaxInputLength = 256
+
+// ansiEscape matches CSI sequences (colors, cursor movement) and two-byte ESC sequences
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b[@-Z\\-_]`)
+
+// Sanitize strips CR/LF, ANSI escapes and other control characters from a user-supplied value
+// and truncates it to DefaultMaxInputLength, so it can't forge or corrupt log lines
+func Sanitize(s string) string {
+	return SanitizeN(s, DefaultMaxInputLength)
+}
+
+// SanitizeN is like Sanitize with a custom maximum length in runes; maxLen <= 0 disables truncation
+func SanitizeN(s string, maxLen int) string {
+	s = ansiEscape.ReplaceAllString(s, "")
+	s = strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+
+	if maxLen > 0 && utf8.RuneCountInString(s) > maxLen {
+		runes := []rune(s)
+		s = string(runes[:maxLen]) + "..."
+	}
+	return s
+}
+
+// SanitizeFields returns a copy of fields with every string value sanitized
+func SanitizeFields(fields map[string]interface{}, maxLen int) map[string]interface{} {
+	if fields == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(fields))
+	for k, val := range fields {
+		if s, ok := val.(string); ok {
+			val = SanitizeN(s, maxLen)
+		}
+		out[k] = val
+	}
+	return out
+}
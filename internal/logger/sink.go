@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Sink is an additional destination for log batches. Every batch sent to VictoriaLogs is
+// also written to each sink in Config.Sinks; Config.Output replaces VictoriaLogs with a sink.
+type Sink interface {
+	Write(ctx context.Context, entries []LogEntry) error
+	Close() error
+}
+
+// WriterSink encodes entries to an io.Writer, e.g. os.Stdout for `kubectl logs`
+type WriterSink struct {
+	mu      sync.Mutex
+	w       io.Writer
+	encoder Encoder
+	buf     bytes.Buffer
+}
+
+// NewWriterSink returns a sink writing to w with enc, or as VictoriaLogs JSON lines when enc is nil
+func NewWriterSink(w io.Writer, enc Encoder) *WriterSink {
+	if enc == nil {
+		enc = JSONLinesEncoder{}
+	}
+	return &WriterSink{w: w, encoder: enc}
+}
+
+func (s *WriterSink) Write(ctx context.Context, entries []LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.Reset()
+	for _, entry := range entries {
+		if err := s.encoder.Encode(&s.buf, entry); err != nil {
+			return err
+		}
+	}
+	_, err := s.w.Write(s.buf.Bytes())
+	return err
+}
+
+// Close is a no-op: the writer is owned by the caller
+func (s *WriterSink) Close() error {
+	return nil
+}
+
+// NewStdoutSink writes VictoriaLogs JSON lines to stdout, encoded according to config (which may be nil)
+func NewStdoutSink(config *Config) *WriterSink {
+	return NewWriterSink(os.Stdout, encoderFor(config))
+}
+
+// NewStderrSink is NewStdoutSink for stderr
+func NewStderrSink(config *Config) *WriterSink {
+	return NewWriterSink(os.Stderr, encoderFor(config))
+}
+
+func encoderFor(config *Config) Encoder {
+	if config == nil {
+		return JSONLinesEncoder{}
+	}
+	return NewEncoder(config)
+}
+
+// writeSinks hands the batch to every configured sink
+func (v *VictoriaLogsLogger) writeSinks(batch []LogEntry) {
+	for _, sink := range v.cfg().Sinks {
+		ctx, cancel := v.requestContext()
+		err := sink.Write(ctx, batch)
+		cancel()
+		if err != nil {
+			v.reportError(fmt.Errorf("sink: %w", err))
+		}
+	}
+}
+
+func (v *VictoriaLogsLogger) closeSinks() error {
+	var errs []error
+	if v.cfg().Output != nil {
+		if err := v.cfg().Output.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, sink := range v.cfg().Sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if v.cfg().FallbackSink != nil {
+		if err := v.cfg().FallbackSink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fallback writes a batch VictoriaLogs did not accept to Config.FallbackSink, or stderr when unset
+func (v *VictoriaLogsLogger) fallback(batchID string, batch []LogEntry) {
+	sink := v.cfg().FallbackSink
+	if sink == nil {
+		sink = stderrSink
+	}
+	if err := sink.Write(context.Background(), batch); err != nil {
+		v.reportError(fmt.Errorf("batch %s: fallback failed, %d entries lost: %w", batchID, len(batch), err))
+	}
+}
+
+var stderrSink = NewWriterSink(os.Stderr, nil)
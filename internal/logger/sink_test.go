@@ -0,0 +1,63 @@
+package logger_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+	"github.com/anhdnyopaz/go_victorialog/internal/logger/logtest"
+)
+
+type recordingSink struct {
+	mu      sync.Mutex
+	entries []logger.LogEntry
+	closed  bool
+}
+
+func (s *recordingSink) Write(ctx context.Context, entries []logger.LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entries...)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func TestOutputReplacesVictoriaLogs(t *testing.T) {
+	fake := logtest.NewFakeVictoriaLogs(t)
+	output := &recordingSink{}
+	l := fake.NewLogger(t, func(c *logger.Config) { c.Output = output })
+
+	l.Info(context.Background(), "Exported", nil)
+	if err := l.Audit(context.Background(), "user_deleted", nil); err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if n := fake.Requests(); n != 0 {
+		t.Errorf("%d requests sent to VictoriaLogs, want none", n)
+	}
+	if len(output.entries) != 2 {
+		t.Errorf("output got %d entries, want 2", len(output.entries))
+	}
+	if !output.closed {
+		t.Error("output was not closed")
+	}
+}
+
+func TestOutputRejectsConsoleFormat(t *testing.T) {
+	config := logtest.SyncConfig("http://localhost:9428/insert/jsonline")
+	config.Format = logger.FormatConsole
+	config.Output = &recordingSink{}
+	if _, err := logger.NewVictoriaLogsLogger(config); err == nil {
+		t.Error("Output was accepted with FormatConsole")
+	}
+}
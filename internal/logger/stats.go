@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats describes the delivery pipeline of a logger and every logger derived from it since it
+// was created
+type Stats struct {
+	// Buffered is the number of entries waiting in memory, Queued those waiting in the disk queue
+	Buffered int    `json:"buffered"`
+	Queued   uint64 `json:"queued"`
+	// Dropped counts entries that will not reach VictoriaLogs, by reason in DroppedByReason:
+	// buffer_full, queue_full, entry_too_large, closed or delivery_failed
+	Dropped         uint64            `json:"dropped"`
+	DroppedByReason map[string]uint64 `json:"dropped_by_reason,omitempty"`
+	// BatchesSent, EntriesSent and BytesSent count insert requests VictoriaLogs accepted and
+	// their contents; FailedRequests counts attempts that failed, retried or not
+	BatchesSent    uint64 `json:"batches_sent"`
+	EntriesSent    uint64 `json:"entries_sent"`
+	BytesSent      uint64 `json:"bytes_sent"`
+	FailedRequests uint64 `json:"failed_requests"`
+	// LastError is the latest failed attempt and LastErrorTime when it happened
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorTime time.Time `json:"last_error_time,omitzero"`
+	LastSuccess   time.Time `json:"last_success,omitzero"`
+}
+
+// Drop reasons in Stats.DroppedByReason
+const (
+	dropBufferFull     = "buffer_full"
+	dropQueueFull      = "queue_full"
+	dropEntryTooLarge  = "entry_too_large"
+	dropClosed         = "closed"
+	dropDeliveryFailed = "delivery_failed"
+)
+
+// pipelineStats is shared with child loggers
+type pipelineStats struct {
+	batchesSent    atomic.Uint64
+	entriesSent    atomic.Uint64
+	bytesSent      atomic.Uint64
+	failedRequests atomic.Uint64
+
+	mu            sync.Mutex
+	drops         map[string]uint64
+	lastError     string
+	lastErrorTime time.Time
+	lastSuccess   time.Time
+}
+
+func newPipelineStats() *pipelineStats {
+	return &pipelineStats{drops: make(map[string]uint64)}
+}
+
+func dropReason(err error) string {
+	switch {
+	case errors.Is(err, ErrBufferFull):
+		return dropBufferFull
+	case errors.Is(err, ErrQueueFull):
+		return dropQueueFull
+	case errors.Is(err, ErrEntryTooLarge):
+		return dropEntryTooLarge
+	case errors.Is(err, ErrClosed):
+		return dropClosed
+	}
+	return dropDeliveryFailed
+}
+
+func (s *pipelineStats) dropped(n int, reason error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drops[dropReason(reason)] += uint64(n)
+}
+
+func (s *pipelineStats) sent(entries, bytes int, now time.Time) {
+	s.batchesSent.Add(1)
+	s.entriesSent.Add(uint64(entries))
+	s.bytesSent.Add(uint64(bytes))
+	s.mu.Lock()
+	s.lastSuccess = now
+	s.mu.Unlock()
+}
+
+func (s *pipelineStats) failed(err error, now time.Time) {
+	s.failedRequests.Add(1)
+	s.mu.Lock()
+	s.lastError = err.Error()
+	s.lastErrorTime = now
+	s.mu.Unlock()
+}
+
+// batchSent counts a request that was accepted and reports it to Config.OnBatchSent
+func (v *VictoriaLogsLogger) batchSent(n, bytes int, start time.Time) {
+	now := v.cfg().clock().Now()
+	v.stats.sent(n, bytes, now)
+	if fn := v.cfg().OnBatchSent; fn != nil {
+		fn(n, bytes, now.Sub(start))
+	}
+}
+
+// batchFailed reports a request given up on to Config.OnBatchFailed
+func (v *VictoriaLogsLogger) batchFailed(n int, err error) {
+	if fn := v.cfg().OnBatchFailed; fn != nil {
+		fn(n, err)
+	}
+}
+
+// Stats returns a snapshot of the pipeline counters
+func (v *VictoriaLogsLogger) Stats() Stats {
+	s := v.stats
+	stats := Stats{
+		Buffered:       len(v.buffer) + len(v.priority),
+		Queued:         v.queue.pending(),
+		BatchesSent:    s.batchesSent.Load(),
+		EntriesSent:    s.entriesSent.Load(),
+		BytesSent:      s.bytesSent.Load(),
+		FailedRequests: s.failedRequests.Load(),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.drops) > 0 {
+		stats.DroppedByReason = make(map[string]uint64, len(s.drops))
+		for reason, n := range s.drops {
+			stats.DroppedByReason[reason] = n
+			stats.Dropped += n
+		}
+	}
+	stats.LastError = s.lastError
+	stats.LastErrorTime = s.lastErrorTime
+	stats.LastSuccess = s.lastSuccess
+	return stats
+}
@@ -0,0 +1,217 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Syslog facilities for SyslogSink.Facility
+const (
+	FacilityUser   = 1
+	FacilityLocal0 = 16
+)
+
+// syslogSDID names the structured data element holding entry fields. 32473 is the
+// private enterprise number reserved for documentation (RFC 5612).
+const syslogSDID = "fields@32473"
+
+// SyslogSink sends entries as RFC 5424 messages over UDP, TCP (octet-counted framing,
+// RFC 6587) or a unix socket. Fields, trace_id and user_id go to a structured data element.
+type SyslogSink struct {
+	// Facility is combined with the entry level into PRI; NewSyslogSink sets FacilityLocal0
+	Facility int
+	// AppName is used when an entry has no service
+	AppName string
+
+	mu       sync.Mutex
+	network  string
+	addr     string
+	conn     net.Conn
+	hostname string
+	pid      string
+	buf      bytes.Buffer
+}
+
+// NewSyslogSink returns a sink for network "udp", "tcp", "unix" or "unixgram" and addr,
+// e.g. NewSyslogSink("udp", "siem:514", "demo-api"). The connection is opened on first write.
+func NewSyslogSink(network, addr, appName string) (*SyslogSink, error) {
+	switch network {
+	case "udp", "tcp", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network %q", network)
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &SyslogSink{
+		Facility: FacilityLocal0,
+		AppName:  appName,
+		network:  network,
+		addr:     addr,
+		hostname: hostname,
+		pid:      strconv.Itoa(os.Getpid()),
+	}, nil
+}
+
+func (s *SyslogSink) Write(ctx context.Context, entries []LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range entries {
+		s.buf.Reset()
+		s.format(&s.buf, entry)
+		if err := s.send(ctx, s.buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send writes one message, reconnecting once if the connection was lost
+func (s *SyslogSink) send(ctx context.Context, msg []byte) error {
+	frame := msg
+	if s.network == "tcp" {
+		frame = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			var d net.Dialer
+			if s.conn, err = d.DialContext(ctx, s.network, s.addr); err != nil {
+				return fmt.Errorf("syslog dial: %w", err)
+			}
+		}
+		if _, err = s.conn.Write(frame); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	return fmt.Errorf("syslog write: %w", err)
+}
+
+// format writes entry as <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG
+func (s *SyslogSink) format(buf *bytes.Buffer, entry LogEntry) {
+	appName := entry.Service
+	if appName == "" {
+		appName = s.AppName
+	}
+	msgID := entry.Stream
+	fmt.Fprintf(buf, "<%d>1 %s %s %s %s %s ",
+		s.Facility*8+syslogSeverity(entry.Level),
+		time.Unix(0, entry.Timestamp).UTC().Format(time.RFC3339Nano),
+		syslogHeader(s.hostname, 255),
+		syslogHeader(appName, 48),
+		s.pid,
+		syslogHeader(msgID, 32),
+	)
+	writeStructuredData(buf, entry)
+	if entry.Message != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(entry.Message)
+	}
+}
+
+func writeStructuredData(buf *bytes.Buffer, entry LogEntry) {
+	params := make(map[string]interface{}, len(entry.Fields)+2)
+	flattenInto(params, "", normalizeFields(entry.Fields))
+	if entry.TraceID != "" {
+		params[TraceIDKey] = entry.TraceID
+	}
+	if entry.UserID != "" {
+		params[UserIDKey] = entry.UserID
+	}
+	if len(params) == 0 {
+		buf.WriteByte('-')
+		return
+	}
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf.WriteString("[" + syslogSDID)
+	for _, name := range names {
+		buf.WriteByte(' ')
+		buf.WriteString(sdName(name))
+		buf.WriteString(`="`)
+		buf.WriteString(sdEscaper.Replace(fmt.Sprint(params[name])))
+		buf.WriteByte('"')
+	}
+	buf.WriteByte(']')
+}
+
+// sdEscaper escapes the characters RFC 5424 requires in PARAM-VALUE
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// sdName turns a field name into a valid SD-NAME: at most 32 printable ASCII characters
+// other than '=', ' ', ']' and '"'
+func sdName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, printableASCII(name))
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
+
+// syslogHeader returns value as a header field of at most maxLen printable characters, or "-" when empty
+func syslogHeader(value string, maxLen int) string {
+	value = printableASCII(value)
+	if value == "" {
+		return "-"
+	}
+	if len(value) > maxLen {
+		value = value[:maxLen]
+	}
+	return value
+}
+
+// printableASCII replaces spaces, control and non-ASCII characters with '_'
+func printableASCII(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r >= 127 {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+func syslogSeverity(level LogLevel) int {
+	switch level {
+	case DEBUG:
+		return 7
+	case INFO:
+		return 6
+	case WARN:
+		return 4
+	case ERROR:
+		return 3
+	default:
+		return 2
+	}
+}
+
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
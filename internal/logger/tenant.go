@@ -0,0 +1,54 @@
+package logger
+
+import "net/http"
+
+// Tenant identifies a VictoriaLogs tenant. It is sent as the AccountID and ProjectID headers;
+// empty values leave the header out, which VictoriaLogs treats as 0.
+type Tenant struct {
+	AccountID string `yaml:"account_id" json:"account_id,omitempty"`
+	ProjectID string `yaml:"project_id" json:"project_id,omitempty"`
+}
+
+func (t Tenant) apply(req *http.Request) {
+	if t.AccountID != "" {
+		req.Header.Set("AccountID", t.AccountID)
+	}
+	if t.ProjectID != "" {
+		req.Header.Set("ProjectID", t.ProjectID)
+	}
+}
+
+// WithTenant returns a child logger whose entries are sent to the given tenant instead of Config.Tenant
+func (v *VictoriaLogsLogger) WithTenant(accountID, projectID string) Logger {
+	newLogger := v.clone()
+	newLogger.tenant = Tenant{AccountID: accountID, ProjectID: projectID}
+	return newLogger
+}
+
+// groupByTenant splits a batch into one batch per tenant, keeping the order of entries in each
+func groupByTenant(batch []LogEntry) [][]LogEntry {
+	first := batch[0].Tenant
+	mixed := false
+	for _, entry := range batch[1:] {
+		if entry.Tenant != first {
+			mixed = true
+			break
+		}
+	}
+	if !mixed {
+		return [][]LogEntry{batch}
+	}
+
+	var groups [][]LogEntry
+	index := make(map[Tenant]int)
+	for _, entry := range batch {
+		i, ok := index[entry.Tenant]
+		if !ok {
+			i = len(groups)
+			index[entry.Tenant] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], entry)
+	}
+	return groups
+}
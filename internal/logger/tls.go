@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSFiles configures TLS from PEM files, for private CAs and mTLS client certificates
+type TLSFiles struct {
+	// CAFile is added to the system roots to verify the VictoriaLogs server
+	CAFile string `yaml:"ca_file"`
+	// CertFile and KeyFile hold the client certificate presented for mTLS
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ServerName overrides the name checked against the server certificate
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+func (f TLSFiles) empty() bool {
+	return f == TLSFiles{}
+}
+
+// tlsConfig combines Config.TLSConfig with Config.TLS, or returns nil when neither is set
+func (c *Config) tlsConfig() (*tls.Config, error) {
+	if c.TLSConfig == nil && c.TLS.empty() {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.TLSConfig != nil {
+		cfg = c.TLSConfig.Clone()
+	}
+
+	files := c.TLS
+	if files.CAFile != "" {
+		pem, err := os.ReadFile(files.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", files.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if files.CertFile != "" || files.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = append(cfg.Certificates, cert)
+	}
+	if files.ServerName != "" {
+		cfg.ServerName = files.ServerName
+	}
+	if files.InsecureSkipVerify {
+		cfg.InsecureSkipVerify = true
+	}
+	return cfg, nil
+}
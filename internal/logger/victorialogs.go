@@ -3,26 +3,48 @@ package logger
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type VictoriaLogsLogger struct {
-	config    *Config
-	client    *http.Client
-	buffer    chan LogEntry
-	batchChan chan []LogEntry
-	wg        sync.WaitGroup
-	ctx       context.Context
-	cancel    context.CancelFunc
+	// settings holds the config and what is built from it, shared with child loggers and
+	// replaced as a whole by Reload
+	settings *atomic.Pointer[loggerSettings]
+	client   *http.Client
+	buffer   chan LogEntry
+	// priority buffers ERROR and above ahead of buffer, see Config.PriorityBufferSize
+	priority chan LogEntry
+	// ctx is the context given to WithContext, used for entries logged with a nil context;
+	// shutdown is tracked by life.ctx
+	ctx context.Context
+	// life is shared with child loggers so closing any of them shuts the pipeline down once
+	life *lifecycle
+	// flushReq asks the async worker to send everything it holds and report the result on the channel it is given
+	flushReq chan chan error
+	// level is shared with child loggers so SetLevel affects all of them
+	level *atomic.Int32
+	hooks *hookChain
+	stats *pipelineStats
+	// dedup is nil unless Config.DedupWindow is set
+	dedup *deduplicator
+	// queue is nil unless Config.Queue.Dir is set
+	queue *diskQueue
+	// output replaces VictoriaLogs delivery when Config.Output is set or Config.Format is
+	// FormatConsole or FormatStdout
+	output Sink
 
 	//Context Fields
 	contextFields map[string]interface{}
 	serviceName   string
+	name          string
+	tenant        Tenant
 	mu            sync.RWMutex //Need to know RWMutex
 }
 
@@ -31,24 +53,35 @@ type VictoriaLogsEntry struct {
 	Time   time.Time `json:"_time"`
 	Stream string    `json:"_stream,omitempty"`
 	// Custom fields
-	Level   string `json:"level,omitempty"`
-	Service string `json:"service,omitempty"`
-	TraceId string `json:"trace_id,omitempty"`
-	UserId  string `json:"user_id,omitempty"`
+	Level     string `json:"level,omitempty"`
+	Service   string `json:"service,omitempty"`
+	TraceId   string `json:"trace_id,omitempty"`
+	UserId    string `json:"user_id,omitempty"`
+	LogStream string `json:"stream,omitempty"`
 	// AdditionalFields
 	Fields map[string]interface{} `json:"fields,omitempty"`
 }
 
-func (v *VictoriaLogsLogger) WithContext(ctx context.Context) Logger {
+// clone returns a child logger sharing the pipeline of v with a copy of its context fields
+func (v *VictoriaLogsLogger) clone() *VictoriaLogsLogger {
 	newLogger := &VictoriaLogsLogger{
-		config:        v.config,
+		settings:      v.settings,
 		client:        v.client,
 		buffer:        v.buffer,
-		batchChan:     v.batchChan,
-		ctx:           ctx,
-		cancel:        v.cancel,
+		priority:      v.priority,
+		flushReq:      v.flushReq,
+		life:          v.life,
+		ctx:           v.ctx,
+		level:         v.level,
+		hooks:         v.hooks,
+		stats:         v.stats,
+		dedup:         v.dedup,
+		queue:         v.queue,
+		output:        v.output,
 		contextFields: make(map[string]interface{}),
 		serviceName:   v.serviceName,
+		name:          v.name,
+		tenant:        v.tenant,
 	}
 	v.mu.RLock()
 	for k, v := range v.contextFields {
@@ -59,23 +92,16 @@ func (v *VictoriaLogsLogger) WithContext(ctx context.Context) Logger {
 	return newLogger
 }
 
-func (v *VictoriaLogsLogger) WithFields(fields map[string]interface{}) Logger {
-	newLogger := &VictoriaLogsLogger{
-		config:        v.config,
-		client:        v.client,
-		buffer:        v.buffer,
-		batchChan:     v.batchChan,
-		ctx:           v.ctx,
-		cancel:        v.cancel,
-		contextFields: make(map[string]interface{}),
-		serviceName:   v.serviceName,
-	}
-	v.mu.RLock()
-	for k, v := range v.contextFields {
-		newLogger.contextFields[k] = v
-	}
-	v.mu.RUnlock()
+// WithContext returns a child logger using ctx for calls made with a nil context. Cancelling
+// ctx does not affect delivery or the pipeline shared with v.
+func (v *VictoriaLogsLogger) WithContext(ctx context.Context) Logger {
+	newLogger := v.clone()
+	newLogger.ctx = ctx
+	return newLogger
+}
 
+func (v *VictoriaLogsLogger) WithFields(fields map[string]interface{}) Logger {
+	newLogger := v.clone()
 	for k, v := range fields {
 		newLogger.contextFields[k] = v
 	}
@@ -83,25 +109,36 @@ func (v *VictoriaLogsLogger) WithFields(fields map[string]interface{}) Logger {
 }
 
 func (v *VictoriaLogsLogger) WithService(service string) Logger {
-	newLogger := &VictoriaLogsLogger{
-		config:        v.config,
-		client:        v.client,
-		buffer:        v.buffer,
-		batchChan:     v.batchChan,
-		ctx:           v.ctx,
-		cancel:        v.cancel,
-		contextFields: make(map[string]interface{}),
-		serviceName:   service,
-	}
-	v.mu.RLock()
-	for k, v := range v.contextFields {
-		newLogger.contextFields[k] = v
-	}
-	v.mu.RUnlock()
+	newLogger := v.clone()
+	newLogger.serviceName = service
+	return newLogger
+}
 
+// WithName returns a child logger whose name is appended to the parent's with a dot,
+// e.g. "api" -> "api.users" -> "api.users.repo". The name is emitted as the "logger" field.
+func (v *VictoriaLogsLogger) WithName(name string) Logger {
+	newLogger := v.clone()
+	if v.name != "" {
+		name = v.name + "." + name
+	}
+	newLogger.name = name
 	return newLogger
 }
 
+func (v *VictoriaLogsLogger) Enabled(level LogLevel) bool {
+	return level >= v.GetLevel()
+}
+
+// SetLevel changes the minimum level at runtime for this logger and every logger derived from it
+func (v *VictoriaLogsLogger) SetLevel(level LogLevel) {
+	v.level.Store(int32(level))
+}
+
+// GetLevel returns the current minimum level
+func (v *VictoriaLogsLogger) GetLevel() LogLevel {
+	return LogLevel(v.level.Load())
+}
+
 func (v *VictoriaLogsLogger) Debug(ctx context.Context, msg string, fields map[string]interface{}) {
 	v.log(ctx, DEBUG, msg, fields)
 }
@@ -118,133 +155,502 @@ func (v *VictoriaLogsLogger) Error(ctx context.Context, msg string, fields map[s
 	v.log(ctx, ERROR, msg, fields)
 }
 
+// Fatal logs the entry, waits for it to be delivered and then exits the process via Config.ExitFunc
 func (v *VictoriaLogsLogger) Fatal(ctx context.Context, msg string, fields map[string]interface{}) {
 	v.log(ctx, FATAL, msg, fields)
+	v.exit()
 }
 
-func (v *VictoriaLogsLogger) BatchLog(entries []LogEntry) error {
-	if v.config.Async {
-		for _, entry := range entries {
-			select {
-			case v.buffer <- entry:
-			default:
-				return fmt.Errorf("buffer full")
-			}
+// Panic logs the entry, waits for it to be delivered and then panics with msg
+func (v *VictoriaLogsLogger) Panic(ctx context.Context, msg string, fields map[string]interface{}) {
+	v.log(ctx, PANIC, msg, fields)
+	panic(msg)
+}
+
+// Log writes an entry at an explicit level. It never exits or panics, whatever the level,
+// which makes it the entry point for adapters bridging other logging APIs.
+func (v *VictoriaLogsLogger) Log(ctx context.Context, level LogLevel, msg string, fields map[string]interface{}) {
+	v.log(ctx, level, msg, fields)
+}
+
+func (v *VictoriaLogsLogger) Debugf(ctx context.Context, format string, args ...interface{}) {
+	msg, fields := formatMessage(format, args)
+	v.log(ctx, DEBUG, msg, fields)
+}
+
+func (v *VictoriaLogsLogger) Infof(ctx context.Context, format string, args ...interface{}) {
+	msg, fields := formatMessage(format, args)
+	v.log(ctx, INFO, msg, fields)
+}
+
+func (v *VictoriaLogsLogger) Warnf(ctx context.Context, format string, args ...interface{}) {
+	msg, fields := formatMessage(format, args)
+	v.log(ctx, WARN, msg, fields)
+}
+
+func (v *VictoriaLogsLogger) Errorf(ctx context.Context, format string, args ...interface{}) {
+	msg, fields := formatMessage(format, args)
+	v.log(ctx, ERROR, msg, fields)
+}
+
+func (v *VictoriaLogsLogger) Fatalf(ctx context.Context, format string, args ...interface{}) {
+	msg, fields := formatMessage(format, args)
+	v.log(ctx, FATAL, msg, fields)
+	v.exit()
+}
+
+func (v *VictoriaLogsLogger) Panicf(ctx context.Context, format string, args ...interface{}) {
+	msg, fields := formatMessage(format, args)
+	v.log(ctx, PANIC, msg, fields)
+	panic(msg)
+}
+
+func (v *VictoriaLogsLogger) Debugw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	v.log(ctx, DEBUG, msg, fieldsFromKeysAndValues(keysAndValues))
+}
+
+func (v *VictoriaLogsLogger) Infow(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	v.log(ctx, INFO, msg, fieldsFromKeysAndValues(keysAndValues))
+}
+
+func (v *VictoriaLogsLogger) Warnw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	v.log(ctx, WARN, msg, fieldsFromKeysAndValues(keysAndValues))
+}
+
+func (v *VictoriaLogsLogger) Errorw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	v.log(ctx, ERROR, msg, fieldsFromKeysAndValues(keysAndValues))
+}
+
+func (v *VictoriaLogsLogger) Fatalw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	v.log(ctx, FATAL, msg, fieldsFromKeysAndValues(keysAndValues))
+	v.exit()
+}
+
+func (v *VictoriaLogsLogger) Panicw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	v.log(ctx, PANIC, msg, fieldsFromKeysAndValues(keysAndValues))
+	panic(msg)
+}
+
+// BatchLog logs caller-built entries without blocking and returns how many were accepted.
+// It stops at the first entry that does not fit, so entries[n:] were not logged; they are
+// also passed to OnDrop. In sync mode the entries are sent at once and the delivery error
+// is returned. Entries dropped by hooks count as accepted.
+func (v *VictoriaLogsLogger) BatchLog(entries []LogEntry) (int, error) {
+	return v.batchLog(nil, entries)
+}
+
+// BatchLogContext is BatchLog waiting for room in the buffer until ctx ends, when it returns
+// the number of entries accepted so far and ctx.Err()
+func (v *VictoriaLogsLogger) BatchLogContext(ctx context.Context, entries []LogEntry) (int, error) {
+	return v.batchLog(ctx, entries)
+}
+
+func (v *VictoriaLogsLogger) batchLog(ctx context.Context, entries []LogEntry) (int, error) {
+	if !v.cfg().Async {
+		if v.life.closed.Load() {
+			v.drop(entries, ErrClosed)
+			return 0, ErrClosed
+		}
+		return len(entries), v.sendBatch(v.prepareBatch(entries), true)
+	}
+	for i, entry := range entries {
+		prepared, keep := v.prepareEntry(entry)
+		if !keep {
+			continue
+		}
+		if err := v.accept(ctx, prepared); err != nil {
+			v.drop(entries[i:], err)
+			return i, err
+		}
+	}
+	return len(entries), nil
+}
+
+// accept buffers one BatchLog entry, waiting for room when ctx is not nil
+func (v *VictoriaLogsLogger) accept(ctx context.Context, entry LogEntry) error {
+	switch {
+	case v.life.closed.Load():
+		return ErrClosed
+	case v.queue != nil:
+		return v.queue.append(entry)
+	case ctx == nil:
+		if !v.offer(entry) {
+			return ErrBufferFull
 		}
 		return nil
 	}
-	v.sendBatch(entries)
-	return nil
+	return v.offerWait(ctx, entry)
+}
+
+// prepareBatch runs caller-built entries through the same processing as logged ones,
+// see prepareEntry
+func (v *VictoriaLogsLogger) prepareBatch(entries []LogEntry) []LogEntry {
+	kept := make([]LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if prepared, keep := v.prepareEntry(entry); keep {
+			kept = append(kept, prepared)
+		}
+	}
+	return kept
+}
+
+// prepareEntry applies lazy fields, hooks and field policy to a caller-built entry, reporting
+// whether hooks kept it. Fields are copied so the caller's map stays untouched.
+func (v *VictoriaLogsLogger) prepareEntry(entry LogEntry) (LogEntry, bool) {
+	fields := make(map[string]interface{}, len(entry.Fields))
+	for k, val := range entry.Fields {
+		fields[k] = val
+	}
+	resolveLazy(fields)
+	entry.Fields = fields
+	if entry.Tenant == (Tenant{}) {
+		entry.Tenant = v.tenant
+	}
+	if !v.hooks.run(&entry, v.reportError) {
+		return entry, false
+	}
+	v.cfg().fieldPolicy().applyEntry(&entry)
+	return entry, true
 }
 
-// Flush Đảm bảo tất cả các logs được gửi
-func (v *VictoriaLogsLogger) Flush() error {
-	if !v.config.Async {
+// Flush has the async worker send everything buffered so far and returns once it was delivered,
+// with the delivery errors if some of it was not accepted, or ctx.Err() when ctx ends first.
+// After Close there is nothing left to flush.
+func (v *VictoriaLogsLogger) Flush(ctx context.Context) error {
+	if !v.cfg().Async {
 		return nil
 	}
+	done := make(chan error, 1)
+	select {
+	case v.flushReq <- done:
+	case <-v.life.ctx.Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flushTimeout is Flush bounded by a timeout, reporting whether the buffer was sent in time
+func (v *VictoriaLogsLogger) flushTimeout(timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return !errors.Is(v.Flush(ctx), context.DeadlineExceeded)
+}
 
-	//Đợi buffer rỗng
-	for len(v.buffer) > 0 {
-		time.Sleep(100 * time.Millisecond)
+// deliverNow sends a PANIC or FATAL entry synchronously after giving the buffer a bounded
+// chance to drain, so the entry explaining why the process stops is never left in memory
+func (v *VictoriaLogsLogger) deliverNow(entry LogEntry) {
+	if !v.flushTimeout(v.cfg().fatalFlushTimeout()) {
+		v.reportError(fmt.Errorf("buffer not drained before %s entry", entry.Level))
 	}
-	return nil
+	v.sendBatch([]LogEntry{entry}, true)
 }
 
-func (v *VictoriaLogsLogger) Close() error {
-	v.cancel()
-	v.wg.Wait()
-	close(v.buffer)
-	close(v.batchChan)
-	return nil
+func (v *VictoriaLogsLogger) exit() {
+	if v.cfg().DisableFatalExit {
+		return
+	}
+	if v.cfg().ExitFunc != nil {
+		v.cfg().ExitFunc(1)
+		return
+	}
+	os.Exit(1)
 }
 
-func (v *VictoriaLogsLogger) startAsyncProcessing() {
-	v.wg.Add(1)
-	go func() {
-		defer v.wg.Done()
-		ticker := time.NewTicker(v.config.FlushInterval)
-		defer ticker.Stop()
-
-		batch := v.NewLoggerEntryBatch()
-
-		for {
-			select {
-			case entry := <-v.buffer:
-				batch = append(batch, entry)
-				v.sendBatch(batch)
-				batch = v.NewLoggerEntryBatch()
-			case <-ticker.C:
-				if len(batch) > 0 {
-					v.sendBatch(batch)
-				}
-				batch = v.NewLoggerEntryBatch()
-			case <-v.ctx.Done():
-				if len(batch) > 0 {
-					v.sendBatch(batch)
-				}
-				return
-			}
+// lifecycle tracks shutdown of the pipeline shared by a logger and its children
+type lifecycle struct {
+	// ctx is cancelled by Close once the buffer was drained or DrainTimeout passed, stopping
+	// the background goroutines
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+	// closed stops intake; drainExpired is set once Close stopped waiting for the buffer
+	closed       atomic.Bool
+	drainExpired atomic.Bool
+	// paused is set while the health probe finds VictoriaLogs unreachable; pauseChanged wakes
+	// up the batcher when it is set or cleared
+	paused       atomic.Bool
+	pauseChanged chan struct{}
+	// reloaded tells the batcher to pick up the flush interval of a new config; reloadMu
+	// serializes Reload
+	reloaded chan struct{}
+	reloadMu sync.Mutex
+}
+
+// Close stops accepting entries, waits up to DrainTimeout for buffered ones to be delivered,
+// then closes the disk queue and sinks. Entries still buffered after the timeout go to the
+// fallback sink, or stay in the disk queue for the next start. Close may be called more than
+// once and on any derived logger; later calls return the result of the first.
+func (v *VictoriaLogsLogger) Close() error {
+	v.life.once.Do(func() {
+		v.life.closed.Store(true)
+		v.flushDedup(true)
+		ctx, cancel := context.WithTimeout(context.Background(), v.cfg().drainTimeout())
+		flushErr := v.Flush(ctx)
+		cancel()
+		if errors.Is(flushErr, context.DeadlineExceeded) {
+			v.life.drainExpired.Store(true)
+			flushErr = fmt.Errorf("logger: buffer not drained within %s", v.cfg().drainTimeout())
 		}
-	}()
+		v.life.cancel()
+		v.life.wg.Wait()
+		if prefix := v.cfg().ExpvarPrefix; prefix != "" {
+			v.unpublishExpvar(prefix)
+		}
+		v.life.err = errors.Join(flushErr, v.queue.close(), v.closeSinks())
+	})
+	return v.life.err
+}
+
+// abandon handles entries left when Close stopped waiting: entries from the disk queue stay
+// there for the next start, others are reported as dropped and written to the fallback sink
+func (v *VictoriaLogsLogger) abandon(batch []LogEntry) {
+	if len(batch) == 0 || v.queue != nil {
+		return
+	}
+	v.drop(batch, ErrClosed)
+	v.fallback("shutdown", batch)
 }
 
 func (v *VictoriaLogsLogger) NewLoggerEntryBatch() []LogEntry {
-	return make([]LogEntry, 0, v.config.BatchSize)
+	return make([]LogEntry, 0, v.cfg().BatchSize)
+}
+
+// sendBatch delivers batch to the insert route. With bounded, retries stop after MaxRetries
+// even in at-least-once mode, for callers waiting on the result.
+func (v *VictoriaLogsLogger) sendBatch(batch []LogEntry, bounded bool) error {
+	err := v.deliver(batch, v.settings.Load().insertRoute, bounded)
+	if v.cfg().Delivery != DeliveryAtLeastOnce {
+		v.queue.ack(batch)
+	}
+	return err
 }
 
-func (v *VictoriaLogsLogger) sendBatch(batch []LogEntry) {
-	fmt.Printf("Send batch %v\n", batch)
+// deliver sends the batch to the sinks and to VictoriaLogs through r, one request per tenant.
+// Entries VictoriaLogs did not accept go to the fallback sink and the delivery errors are returned.
+// Unless bounded, at-least-once delivery retries until the batch is accepted, see postPayload.
+func (v *VictoriaLogsLogger) deliver(batch []LogEntry, r route, bounded bool) error {
 	if len(batch) == 0 {
-		return
+		return nil
+	}
+	v.writeSinks(batch)
+	if v.output != nil {
+		ctx, cancel := v.requestContext()
+		defer cancel()
+		start := v.cfg().clock().Now()
+		err := v.output.Write(ctx, batch)
+		if err != nil {
+			v.reportError(fmt.Errorf("output: %w", err))
+			v.stats.failed(err, v.cfg().clock().Now())
+			v.batchFailed(len(batch), err)
+			v.drop(batch, err)
+		} else {
+			v.batchSent(len(batch), 0, start)
+		}
+		v.handedOff(batch)
+		return err
 	}
+	batchID := v.cfg().idGenerator().NewID()
 
-	//Convert to JSONL format
-	var buff bytes.Buffer
-	for _, entry := range batch {
-		vlEntry := VictoriaLogsEntry{
-			Msg:     entry.Message,
-			Time:    time.Unix(0, entry.Timestamp).UTC(),
-			Level:   entry.Level.String(),
-			Service: entry.Service,
-			TraceId: entry.TraceID,
-			UserId:  entry.UserID,
-			Fields:  entry.Fields,
+	var errs []error
+	for _, group := range groupByTenant(batch) {
+		if err := v.post(batchID, group, r, bounded); err != nil {
+			errs = append(errs, err)
 		}
+	}
+	return errors.Join(errs...)
+}
 
-		data, err := json.Marshal(vlEntry)
-		if err != nil {
-			fmt.Println(err.Error())
+// post encodes entries of a single tenant and sends them in requests of at most MaxBatchBytes
+func (v *VictoriaLogsLogger) post(batchID string, batch []LogEntry, r route, bounded bool) error {
+	var errs []error
+	for _, p := range v.encodePayloads(batch) {
+		if err := v.postPayload(batchID, p, r, bounded); err != nil {
+			errs = append(errs, err)
 		}
-		fmt.Printf("Send log data: %v\n", entry)
-		if err != nil {
+	}
+	return errors.Join(errs...)
+}
+
+// payload is the body of one insert request and the entries encoded in it
+type payload struct {
+	entries []LogEntry
+	data    []byte
+}
+
+// encodePayloads converts the batch to JSON lines, starting a new payload whenever the next
+// line would take the current one past MaxBatchBytes. An entry larger than that is dropped.
+func (v *VictoriaLogsLogger) encodePayloads(batch []LogEntry) []payload {
+	limit := v.cfg().maxBatchBytes()
+	encoder := NewEncoder(v.cfg())
+	var (
+		payloads []payload
+		current  payload
+		line     bytes.Buffer
+	)
+	for _, entry := range batch {
+		line.Reset()
+		if err := encoder.Encode(&line, entry); err != nil {
+			v.reportError(fmt.Errorf("encode entry: %w", err))
+			v.drop([]LogEntry{entry}, err)
+			v.handedOff([]LogEntry{entry})
+			continue
+		}
+		if line.Len() > limit {
+			v.drop([]LogEntry{entry}, fmt.Errorf("%w: %d bytes", ErrEntryTooLarge, line.Len()))
+			v.handedOff([]LogEntry{entry})
 			continue
 		}
-		buff.Write(data)
-		buff.WriteByte('\n')
+		if len(current.entries) > 0 && len(current.data)+line.Len() > limit {
+			payloads = append(payloads, current)
+			current = payload{}
+		}
+		current.entries = append(current.entries, entry)
+		current.data = append(current.data, line.Bytes()...)
+	}
+	if len(current.entries) > 0 {
+		payloads = append(payloads, current)
+	}
+	return payloads
+}
+
+// postPayload sends one payload with retries. When every attempt failed its entries go to
+// the fallback sink and the last error is returned. In at-least-once mode a payload that is
+// not bounded is retried until it is accepted or Close gives up.
+func (v *VictoriaLogsLogger) postPayload(batchID string, p payload, r route, bounded bool) error {
+	body, contentEncoding, err := compress(v.cfg().Compression, p.data)
+	if err != nil {
+		v.reportError(fmt.Errorf("batch %s: compress: %w", batchID, err))
+		body, contentEncoding = p.data, ""
 	}
 
 	//Retry logic
-	for i := 0; i < v.config.MaxRetries; i++ {
-		if err := v.sendToVictoriaLogs(buff.Bytes()); err == nil {
-			return
-		} else {
-			fmt.Println(err)
+	var lastErr error
+	start := v.cfg().clock().Now()
+	policy := v.cfg().retryPolicy()
+	atLeastOnce := !bounded && v.cfg().Delivery == DeliveryAtLeastOnce
+	for i := 0; atLeastOnce || i < policy.MaxRetries; i++ {
+		// After shutdown the batch gets one more attempt, so pause can't spin on a done context
+		if atLeastOnce && (v.life.drainExpired.Load() || i > 0 && v.life.ctx.Err() != nil) {
+			break
+		}
+		// At-least-once keeps retrying past MaxRetries; the backoff stays at its last step
+		attempt := min(i, policy.MaxRetries)
+		if !r.breaker.allow(v.cfg().clock().Now()) {
+			if atLeastOnce {
+				v.pause(policy.Backoff.Delay(attempt))
+				continue
+			}
+			lastErr = errors.Join(ErrCircuitOpen, lastErr)
+			break
+		}
+		ep := r.endpoints.pick(v.cfg().clock().Now())
+		insertURL, err := v.cfg().ingestURL(ep.url, r.streamFields)
+		if err == nil {
+			err = v.sendToVictoriaLogs(insertURL, p.entries[0].Tenant, body, contentEncoding)
+		}
+		if err == nil {
+			r.endpoints.report(ep, nil, v.cfg().clock().Now())
+			r.breaker.success()
+			v.batchSent(len(p.entries), len(body), start)
+			v.handedOff(p.entries)
+			return nil
+		}
+		lastErr = err
+		v.stats.failed(err, v.cfg().clock().Now())
+		v.reportError(fmt.Errorf("batch %s: attempt %d: %s: %w", batchID, i+1, ep.url, err))
+		// A permanent rejection is about the batch, not the endpoint, and won't succeed elsewhere
+		if !policy.retryable(err) {
+			r.breaker.success()
+			break
+		}
+		r.endpoints.report(ep, err, v.cfg().clock().Now())
+		circuitOpen := r.breaker.failure(v.cfg().clock().Now())
+		// Retry right away when another endpoint is still healthy
+		wait := retryAfter(err)
+		if wait == 0 && !r.endpoints.healthyAvailable(v.cfg().clock().Now()) {
+			wait = policy.Backoff.Delay(attempt)
 		}
-		time.Sleep(time.Duration(i+1) * time.Second)
+		if atLeastOnce {
+			if !circuitOpen {
+				v.pause(wait)
+			}
+			continue
+		}
+		// Once Close has been called the batch gets a single attempt, so shutdown isn't held up by retries
+		if circuitOpen || v.life.ctx.Err() != nil || i == policy.MaxRetries-1 {
+			break
+		}
+		if !policy.budgetLeft(start, v.cfg().clock().Now(), wait) {
+			break
+		}
+		v.cfg().clock().Sleep(wait)
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("batch %s: no delivery attempt made", batchID)
+	}
+	if atLeastOnce && v.queue != nil && (v.life.drainExpired.Load() || v.life.ctx.Err() != nil) {
+		// Close gave up: the entries stay in the disk queue for the next start
+		return lastErr
 	}
+	v.batchFailed(len(p.entries), lastErr)
+	v.drop(p.entries, lastErr)
+	v.fallback(batchID, p.entries)
+	v.handedOff(p.entries)
+	return lastErr
 }
 
-func (v *VictoriaLogsLogger) sendToVictoriaLogs(data []byte) error {
+// pause waits d, or less when the logger shuts down
+func (v *VictoriaLogsLogger) pause(d time.Duration) {
+	select {
+	case <-v.cfg().clock().After(d):
+	case <-v.life.ctx.Done():
+	}
+}
+
+// handedOff acknowledges entries in the disk queue once they were delivered, or rejected or
+// dropped for good, in at-least-once mode. Otherwise sendBatch acknowledges whole batches.
+func (v *VictoriaLogsLogger) handedOff(entries []LogEntry) {
+	if v.cfg().Delivery == DeliveryAtLeastOnce {
+		v.queue.ack(entries)
+	}
+}
+
+// requestContext returns the context for one delivery request. It is bounded by Config.Timeout
+// rather than derived from the logger context, which Close cancels before the final batch is sent.
+func (v *VictoriaLogsLogger) requestContext() (context.Context, context.CancelFunc) {
+	if v.cfg().Timeout > 0 {
+		return context.WithTimeout(context.Background(), v.cfg().Timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+func (v *VictoriaLogsLogger) sendToVictoriaLogs(insertURL string, tenant Tenant, data []byte, contentEncoding string) error {
+	ctx, cancel := v.requestContext()
+	defer cancel()
 	req, err := http.NewRequestWithContext(
-		v.ctx,
+		ctx,
 		"POST",
-		v.config.VictoriaLogsURL,
+		insertURL,
 		bytes.NewReader(data),
 	)
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/x-ndjson")
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+	v.cfg().applyHeaders(req)
+	tenant.apply(req)
 	resp, err := v.client.Do(req)
 	if err != nil {
 		return err
@@ -258,51 +664,106 @@ func (v *VictoriaLogsLogger) sendToVictoriaLogs(data []byte) error {
 	}(resp.Body)
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("VictoriaLogs returned status code %d", resp.StatusCode)
+		return newHTTPError(resp, v.cfg().clock().Now())
 	}
 
 	return nil
 }
 
 func (v *VictoriaLogsLogger) log(ctx context.Context, info LogLevel, msg string, fields map[string]interface{}) {
+	if !v.Enabled(info) {
+		return
+	}
+	if ctx == nil {
+		ctx = v.ctx
+	}
+	if sampler := v.settings.Load().sampler; sampler != nil && !sampler.sample(info) {
+		return
+	}
+	if limiter := v.settings.Load().limiter; limiter != nil {
+		v.reportSuppressed()
+		if !limiter.allow(info, v.cfg().clock().Now()) {
+			return
+		}
+	}
 	entry := v.createLogEntry(info, msg, fields)
-
-	if traceID := ctx.Value("trace_id"); traceID != nil {
-		if tid, ok := traceID.(string); ok {
-			entry.TraceID = tid
+	if v.cfg().EnableCaller {
+		addCaller(entry.Fields, v.cfg().CallerSkip)
+	}
+	if v.cfg().StackTraceLevel != nil && info >= *v.cfg().StackTraceLevel {
+		addStack(entry.Fields, v.cfg().CallerSkip)
+	}
+	enrichFromContext(ctx, &entry, v.cfg().contextExtractors())
+	if !v.hooks.run(&entry, v.reportError) {
+		return
+	}
+	// Redacted last, so fields added from ctx and by hooks are covered too
+	v.cfg().fieldPolicy().applyEntry(&entry)
+	// PANIC and FATAL entries are still delivered synchronously after Close
+	if info < PANIC && v.life.closed.Load() {
+		v.drop([]LogEntry{entry}, ErrClosed)
+		return
+	}
+	if v.dedup != nil {
+		keep, summaries := v.dedup.observe(entry, v.cfg().clock().Now())
+		for _, summary := range summaries {
+			v.enqueue(summary)
+		}
+		if !keep {
+			return
 		}
 	}
 
-	if userId := ctx.Value("user_id"); userId != nil {
-		if uid, ok := userId.(string); ok {
-			entry.UserID = uid
-		}
+	if info >= PANIC {
+		v.deliverNow(entry)
+		return
 	}
 
-	if v.config.Async {
-		select {
-		case v.buffer <- entry:
-		default:
+	v.enqueue(entry)
+}
+
+// enqueue buffers the entry in async mode, dropping it when the buffer is full, or sends it right away
+func (v *VictoriaLogsLogger) enqueue(entry LogEntry) {
+	if v.queue != nil {
+		if err := v.queue.append(entry); err != nil {
+			v.reportError(fmt.Errorf("queue: %w, entry dropped", err))
+			v.drop([]LogEntry{entry}, err)
+		}
+		return
+	}
+	if v.cfg().Async {
+		if !v.offer(entry) {
+			v.drop([]LogEntry{entry}, ErrBufferFull)
 		}
 	} else {
-		v.sendBatch([]LogEntry{entry})
+		v.sendBatch([]LogEntry{entry}, true)
 	}
-
 }
 
 func (v *VictoriaLogsLogger) createLogEntry(level LogLevel, msg string, fields map[string]interface{}) LogEntry {
 	v.mu.RLock()
 	defer v.mu.RUnlock()
 
+	merged := make(map[string]interface{}, len(fields)+len(v.contextFields))
+	for k, val := range fields {
+		merged[k] = val
+	}
+	for k, val := range v.contextFields {
+		merged[k] = val
+	}
+	if v.name != "" {
+		merged[LoggerNameKey] = v.name
+	}
+	// Only entries that passed level filtering get here, so lazy fields are evaluated now
+	resolveLazy(merged)
+
 	entry := LogEntry{
 		Level:     level,
 		Message:   msg,
-		Timestamp: time.Now().UnixNano(),
+		Timestamp: v.cfg().clock().Now().UnixNano(),
 		Service:   v.serviceName,
-		Fields:    fields,
-	}
-	for k, v := range v.contextFields {
-		entry.Fields[k] = v
+		Fields:    merged,
+		Tenant:    v.tenant,
 	}
 	return entry
 }
@@ -312,23 +773,92 @@ func NewVictoriaLogsLogger(config *Config) (*VictoriaLogsLogger, error) {
 		config = DefaultConfig()
 	}
 
+	insertRoute, auditRoute, err := config.newRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateCompression(config.Compression); err != nil {
+		return nil, err
+	}
+	if err := config.validateAuth(); err != nil {
+		return nil, err
+	}
+	if err := config.validateFieldPolicies(); err != nil {
+		return nil, err
+	}
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTTP client config: %w", err)
+	}
+
+	var output Sink
+	switch config.Format {
+	case "", FormatJSON:
+	case FormatConsole:
+		output = NewConsoleSink(os.Stdout)
+	case FormatStdout:
+		output = NewStdoutSink(config)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", config.Format)
+	}
+	if config.Output != nil {
+		if output != nil {
+			return nil, fmt.Errorf("format %q cannot be combined with Output", config.Format)
+		}
+		output = config.Output
+	}
+
+	if err := config.validateDelivery(); err != nil {
+		return nil, err
+	}
+
+	var queue *diskQueue
+	if config.Queue.Dir != "" {
+		if !config.Async {
+			return nil, fmt.Errorf("disk queue requires Async")
+		}
+		if queue, err = openDiskQueue(config.Queue, config.errorHandler()); err != nil {
+			return nil, fmt.Errorf("open disk queue: %w", err)
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	logger := &VictoriaLogsLogger{
-		config: config,
-		client: &http.Client{
-			Timeout: config.Timeout,
-		},
+		settings:      new(atomic.Pointer[loggerSettings]),
+		client:        client,
 		buffer:        make(chan LogEntry, config.BufferSize),
-		batchChan:     make(chan []LogEntry, config.BufferSize),
-		ctx:           ctx,
-		cancel:        cancel,
+		priority:      make(chan LogEntry, config.priorityBufferSize()),
+		flushReq:      make(chan chan error),
+		life:          &lifecycle{ctx: ctx, cancel: cancel, pauseChanged: make(chan struct{}, 1), reloaded: make(chan struct{}, 1)},
+		level:         new(atomic.Int32),
+		hooks:         newHookChain(config.Hooks),
+		stats:         newPipelineStats(),
+		dedup:         newDeduplicator(config.DedupWindow),
+		queue:         queue,
+		output:        output,
 		contextFields: make(map[string]interface{}),
 		serviceName:   config.ServiceName,
+		tenant:        config.Tenant,
+	}
+	logger.settings.Store(&loggerSettings{
+		config:      config,
+		insertRoute: insertRoute,
+		auditRoute:  auditRoute,
+		limiter:     newRateLimiter(config.RateLimit, config.clock().Now()),
+		sampler:     newLevelSampler(config.Sampling),
+	})
+	logger.level.Store(int32(config.MinLevel))
+
+	if config.ExpvarPrefix != "" {
+		logger.publishExpvar(config.ExpvarPrefix)
 	}
-
 	if config.Async {
 		logger.startAsyncProcessing()
+		if config.HealthCheckInterval > 0 && output == nil {
+			logger.startHealthProbe()
+		}
 	}
 	return logger, nil
 }
@@ -0,0 +1,39 @@
+// Package vlchi logs the requests served by a chi router, labelled with the route pattern such
+// as "/users/{id}" instead of the raw path, so templated routes don't blow up the number of
+// distinct values.
+//
+//	r := chi.NewRouter()
+//	r.Use(vlchi.Middleware(vlLogger, nil))
+package vlchi
+
+import (
+	"net/http"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+	"github.com/anhdnyopaz/go_victorialog/internal/logger/vlhttp"
+	"github.com/go-chi/chi/v5"
+)
+
+// Middleware is vlhttp.Middleware with the route taken from chi, see RoutePattern. It must be
+// registered with the router's Use, since chi only exposes the route to handlers it runs. A nil
+// config means the zero vlhttp.Config.
+func Middleware(l logger.Logger, config *vlhttp.Config) func(http.Handler) http.Handler {
+	c := vlhttp.Config{}
+	if config != nil {
+		c = *config
+	}
+	if c.Route == nil {
+		c.Route = RoutePattern
+	}
+	return vlhttp.Middleware(l, &c)
+}
+
+// RoutePattern returns the pattern of the chi route the request matched, including the
+// patterns of mounted sub-routers, or "" when none matched
+func RoutePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return ""
+	}
+	return rctx.RoutePattern()
+}
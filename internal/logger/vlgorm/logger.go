@@ -0,0 +1,130 @@
+// Package vlgorm sends GORM's SQL logs to VictoriaLogs as structured entries, with the
+// statement, rows affected and duration as fields.
+//
+//	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: vlgorm.New(vlLogger, nil)})
+package vlgorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// DefaultSlowThreshold is the duration above which queries are logged as slow when
+// Config.SlowThreshold is zero
+const DefaultSlowThreshold = 200 * time.Millisecond
+
+type Config struct {
+	// LogLevel is GORM's level: Silent, Error, Warn (the default) or Info, which logs every query
+	LogLevel gormlogger.LogLevel
+	// SlowThreshold marks slower queries as slow; zero means DefaultSlowThreshold and a negative
+	// value turns slow query logging off
+	SlowThreshold time.Duration
+	// IgnoreRecordNotFoundError doesn't log gorm.ErrRecordNotFound as an error
+	IgnoreRecordNotFoundError bool
+	// ParameterizedQueries logs statements with placeholders instead of the bound values, which
+	// keeps user data out of the logs
+	ParameterizedQueries bool
+}
+
+// Logger implements GORM's logger.Interface: failed queries are logged as ERROR, slow
+// queries as WARN and, with LogLevel Info, every other query as INFO
+type Logger struct {
+	logger logger.Logger
+	config Config
+}
+
+var _ gormlogger.Interface = (*Logger)(nil)
+
+// New returns a GORM logger writing to l; a nil config means the zero Config
+func New(l logger.Logger, config *Config) *Logger {
+	c := Config{}
+	if config != nil {
+		c = *config
+	}
+	if c.LogLevel == 0 {
+		c.LogLevel = gormlogger.Warn
+	}
+	if c.SlowThreshold == 0 {
+		c.SlowThreshold = DefaultSlowThreshold
+	}
+	return &Logger{logger: l, config: c}
+}
+
+// LogMode returns a copy of the logger at level, as used by db.Debug()
+func (g *Logger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *g
+	clone.config.LogLevel = level
+	return &clone
+}
+
+func (g *Logger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if g.config.LogLevel >= gormlogger.Info {
+		g.logger.Info(ctx, fmt.Sprintf(msg, data...), map[string]interface{}{"caller": utils.FileWithLineNum()})
+	}
+}
+
+func (g *Logger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if g.config.LogLevel >= gormlogger.Warn {
+		g.logger.Warn(ctx, fmt.Sprintf(msg, data...), map[string]interface{}{"caller": utils.FileWithLineNum()})
+	}
+}
+
+func (g *Logger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if g.config.LogLevel >= gormlogger.Error {
+		g.logger.Error(ctx, fmt.Sprintf(msg, data...), map[string]interface{}{"caller": utils.FileWithLineNum()})
+	}
+}
+
+// Trace logs a finished query with its sql, rows_affected (when known), duration in
+// milliseconds and the caller in the application
+func (g *Logger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if g.config.LogLevel <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	var level logger.LogLevel
+	var msg string
+	switch {
+	case err != nil && g.config.LogLevel >= gormlogger.Error &&
+		!(g.config.IgnoreRecordNotFoundError && errors.Is(err, gorm.ErrRecordNotFound)):
+		level, msg = logger.ERROR, "SQL query failed"
+	case g.config.SlowThreshold > 0 && elapsed > g.config.SlowThreshold && g.config.LogLevel >= gormlogger.Warn:
+		level, msg = logger.WARN, "Slow SQL query"
+	case g.config.LogLevel >= gormlogger.Info:
+		level, msg = logger.INFO, "SQL query"
+	default:
+		return
+	}
+
+	sql, rows := fc()
+	fields := map[string]interface{}{
+		"sql":      sql,
+		"duration": elapsed.Milliseconds(),
+		"caller":   utils.FileWithLineNum(),
+	}
+	if rows >= 0 {
+		fields["rows_affected"] = rows
+	}
+	if level == logger.WARN {
+		fields["slow_threshold"] = g.config.SlowThreshold.Milliseconds()
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	logger.LogAt(g.logger, ctx, level, msg, fields)
+}
+
+// ParamsFilter drops the bound values with ParameterizedQueries; GORM calls it before logging
+func (g *Logger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if g.config.ParameterizedQueries {
+		return sql, nil
+	}
+	return sql, params
+}
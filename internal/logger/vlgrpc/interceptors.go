@@ -0,0 +1,216 @@
+// Package vlgrpc logs gRPC calls with server and client interceptors and carries the trace ID
+// across services in the request metadata.
+//
+//	srv := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(vlgrpc.UnaryServerInterceptor(vlLogger, nil)),
+//		grpc.ChainStreamInterceptor(vlgrpc.StreamServerInterceptor(vlLogger, nil)),
+//	)
+//	conn, err := grpc.NewClient(target,
+//		grpc.WithChainUnaryInterceptor(vlgrpc.UnaryClientInterceptor(vlLogger, nil)),
+//		grpc.WithChainStreamInterceptor(vlgrpc.StreamClientInterceptor(vlLogger, nil)),
+//	)
+package vlgrpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Messages of the call entries
+const (
+	ServerMessage = "gRPC request completed"
+	ClientMessage = "gRPC call completed"
+)
+
+// DefaultTraceKey is the metadata key carrying the trace ID when Config.TraceKey is empty
+const DefaultTraceKey = "x-trace-id"
+
+type Config struct {
+	// IDGenerator makes the trace ID of server calls that don't carry one; nil means
+	// logger.RandomIDGenerator
+	IDGenerator logger.IDGenerator
+	// TraceKey is the metadata key the trace ID is read from on the server and sent in by the
+	// client; empty means DefaultTraceKey
+	TraceKey string
+	// SkipMethods are full method names that are not logged, e.g. "/grpc.health.v1.Health/Check"
+	SkipMethods []string
+	// Level picks the entry level from the status code; nil means DefaultLevel
+	Level func(code codes.Code) logger.LogLevel
+}
+
+// DefaultLevel logs codes caused by the caller as WARN, server failures as ERROR and
+// everything else, including OK and Canceled, as INFO
+func DefaultLevel(code codes.Code) logger.LogLevel {
+	switch code {
+	case codes.OK, codes.Canceled:
+		return logger.INFO
+	case codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+		codes.Unauthenticated, codes.FailedPrecondition, codes.OutOfRange, codes.ResourceExhausted, codes.Aborted:
+		return logger.WARN
+	default:
+		return logger.ERROR
+	}
+}
+
+// interceptor holds what the interceptors share once the config defaults are applied
+type interceptor struct {
+	logger   logger.Logger
+	ids      logger.IDGenerator
+	traceKey string
+	skip     map[string]bool
+	level    func(codes.Code) logger.LogLevel
+}
+
+func newInterceptor(l logger.Logger, config *Config) *interceptor {
+	if config == nil {
+		config = &Config{}
+	}
+	i := &interceptor{
+		logger:   l,
+		ids:      config.IDGenerator,
+		traceKey: config.TraceKey,
+		skip:     make(map[string]bool, len(config.SkipMethods)),
+		level:    config.Level,
+	}
+	if i.ids == nil {
+		i.ids = logger.RandomIDGenerator{}
+	}
+	if i.traceKey == "" {
+		i.traceKey = DefaultTraceKey
+	}
+	if i.level == nil {
+		i.level = DefaultLevel
+	}
+	for _, method := range config.SkipMethods {
+		i.skip[method] = true
+	}
+	return i
+}
+
+// serverContext returns ctx with the trace ID from the incoming metadata, the context or a new one
+func (i *interceptor) serverContext(ctx context.Context) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(i.traceKey); len(values) > 0 && values[0] != "" {
+			return logger.ContextWithTraceID(ctx, logger.Sanitize(values[0]))
+		}
+	}
+	if logger.TraceIDFromContext(ctx) != "" {
+		return ctx
+	}
+	return logger.ContextWithTraceID(ctx, "trace_"+i.ids.NewID())
+}
+
+// clientContext adds the trace ID of ctx, if any, to the outgoing metadata
+func (i *interceptor) clientContext(ctx context.Context) context.Context {
+	if traceID := logger.TraceIDFromContext(ctx); traceID != "" {
+		return metadata.AppendToOutgoingContext(ctx, i.traceKey, traceID)
+	}
+	return ctx
+}
+
+// log writes the entry for a finished call; peerAddr is the client on the server and the
+// target on the client
+func (i *interceptor) log(ctx context.Context, msg, method, kind, peerAddr string, start time.Time, err error) {
+	st := status.Convert(err)
+	fields := map[string]interface{}{
+		"method":   method,
+		"type":     kind,
+		"code":     st.Code().String(),
+		"duration": time.Since(start).Milliseconds(),
+	}
+	if peerAddr != "" {
+		fields["peer"] = peerAddr
+	}
+	if err != nil {
+		fields["error"] = st.Message()
+	}
+	logger.LogAt(i.logger, ctx, i.level(st.Code()), msg, fields)
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// UnaryServerInterceptor logs every unary call handled by the server
+func UnaryServerInterceptor(l logger.Logger, config *Config) grpc.UnaryServerInterceptor {
+	i := newInterceptor(l, config)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if i.skip[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		start := time.Now()
+		ctx = i.serverContext(ctx)
+		resp, err := handler(ctx, req)
+		i.log(ctx, ServerMessage, info.FullMethod, "unary", peerAddr(ctx), start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor logs every streaming call handled by the server once it ends
+func StreamServerInterceptor(l logger.Logger, config *Config) grpc.StreamServerInterceptor {
+	i := newInterceptor(l, config)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if i.skip[info.FullMethod] {
+			return handler(srv, ss)
+		}
+		start := time.Now()
+		ctx := i.serverContext(ss.Context())
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		i.log(ctx, ServerMessage, info.FullMethod, "stream", peerAddr(ctx), start, err)
+		return err
+	}
+}
+
+// serverStream hands the handler the context carrying the trace ID
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// UnaryClientInterceptor logs every unary call made by the client and sends the trace ID
+func UnaryClientInterceptor(l logger.Logger, config *Config) grpc.UnaryClientInterceptor {
+	i := newInterceptor(l, config)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if i.skip[method] {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		start := time.Now()
+		err := invoker(i.clientContext(ctx), method, req, reply, cc, opts...)
+		i.log(ctx, ClientMessage, method, "unary", cc.Target(), start, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor logs every streaming call made by the client once the server ends
+// it, and sends the trace ID
+func StreamClientInterceptor(l logger.Logger, config *Config) grpc.StreamClientInterceptor {
+	i := newInterceptor(l, config)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if i.skip[method] {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		start := time.Now()
+		cs, err := streamer(i.clientContext(ctx), desc, cc, method, opts...)
+		if err != nil {
+			i.log(ctx, ClientMessage, method, "stream", cc.Target(), start, err)
+			return nil, err
+		}
+		return &clientStream{ClientStream: cs, done: func(err error) {
+			i.log(ctx, ClientMessage, method, "stream", cc.Target(), start, err)
+		}}, nil
+	}
+}
@@ -0,0 +1,181 @@
+package vlhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+)
+
+// DefaultMaxBodySize is how many bytes of each body are logged when BodyConfig.MaxSize is zero
+const DefaultMaxBodySize = 4096
+
+// BodyConfig turns on logging of request and response bodies, as request_body and
+// response_body, for debugging API integrations. Bodies are only captured as the handler reads
+// and writes them, so streaming is unaffected.
+type BodyConfig struct {
+	// MaxSize is how many bytes of each body are logged; zero means DefaultMaxBodySize. Longer
+	// bodies are cut and flagged with request_body_truncated or response_body_truncated.
+	MaxSize int
+	// ContentTypes are the media types whose bodies are logged, "text/*" matching a whole type;
+	// nil means DefaultBodyContentTypes
+	ContentTypes []string
+	// Redact drops or hashes fields of JSON bodies, at any depth, and of form bodies. A
+	// truncated body can't be parsed and is left out rather than logged unredacted.
+	Redact logger.FieldPolicy
+}
+
+// DefaultBodyContentTypes are JSON, form and text bodies
+func DefaultBodyContentTypes() []string {
+	return []string{"application/json", "application/x-www-form-urlencoded", "text/*"}
+}
+
+// allows reports whether bodies of contentType are logged
+func (c *BodyConfig) allows(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	types := c.ContentTypes
+	if types == nil {
+		types = DefaultBodyContentTypes()
+	}
+	for _, t := range types {
+		switch {
+		case t == mediaType:
+			return true
+		case strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(t, "*")):
+			return true
+		case t == "application/json" && strings.HasSuffix(mediaType, "+json"):
+			return true
+		}
+	}
+	return false
+}
+
+// bodyCapture keeps the first bytes of a body whose content type is allowed
+type bodyCapture struct {
+	config      *BodyConfig
+	contentType string
+	decided     bool
+	skip        bool
+	buf         bytes.Buffer
+	truncated   bool
+}
+
+func newBodyCapture(config *BodyConfig) *bodyCapture {
+	return &bodyCapture{config: config}
+}
+
+// write keeps p within MaxSize, deciding on the first call whether contentType is logged
+func (c *bodyCapture) write(contentType string, p []byte) {
+	if !c.decided {
+		c.decided = true
+		c.contentType = contentType
+		c.skip = !c.config.allows(contentType)
+	}
+	if c.skip || len(p) == 0 {
+		return
+	}
+	max := c.config.MaxSize
+	if max <= 0 {
+		max = DefaultMaxBodySize
+	}
+	if room := max - c.buf.Len(); len(p) > room {
+		p = p[:room]
+		c.truncated = true
+	}
+	c.buf.Write(p)
+}
+
+// addTo adds the captured body to fields under name, redacted and optionally sanitized
+func (c *bodyCapture) addTo(fields map[string]interface{}, name string, sanitize bool) {
+	if c == nil || c.skip || c.buf.Len() == 0 {
+		return
+	}
+	if c.truncated {
+		fields[name+"_truncated"] = true
+	}
+	body, ok := redactBody(c.contentType, c.buf.Bytes(), c.truncated, c.config.Redact)
+	if !ok {
+		return
+	}
+	if sanitize {
+		body = logger.SanitizeN(body, 0)
+	}
+	fields[name] = body
+}
+
+// redactBody applies policy to a JSON or form body. It returns false when the body has to be
+// redacted but can't be parsed.
+func redactBody(contentType string, body []byte, truncated bool, policy logger.FieldPolicy) (string, bool) {
+	if len(policy) == 0 {
+		return string(body), true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v interface{}
+		if truncated || json.Unmarshal(body, &v) != nil {
+			return "", false
+		}
+		redactJSON(v, policy)
+		out, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(out), true
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if truncated || err != nil {
+			return "", false
+		}
+		values := make(map[string]interface{}, len(form))
+		for name, value := range form {
+			values[name] = value
+		}
+		policy.Apply(values)
+		redacted := url.Values{}
+		for name, value := range values {
+			switch v := value.(type) {
+			case []string:
+				redacted[name] = v
+			case string:
+				redacted.Set(name, v)
+			}
+		}
+		return redacted.Encode(), true
+	}
+	return string(body), true
+}
+
+func redactJSON(v interface{}, policy logger.FieldPolicy) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		policy.Apply(v)
+		for _, value := range v {
+			redactJSON(value, policy)
+		}
+	case []interface{}:
+		for _, value := range v {
+			redactJSON(value, policy)
+		}
+	}
+}
+
+// captureReader records what the handler reads of the request body
+type captureReader struct {
+	io.ReadCloser
+	contentType string
+	capture     *bodyCapture
+}
+
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.capture.write(r.contentType, p[:n])
+	return n, err
+}
@@ -0,0 +1,134 @@
+// Package vlhttp logs the requests served by net/http handlers, one entry per request once the
+// handler has completed.
+//
+//	router.Use(vlhttp.Middleware(vlLogger, &vlhttp.Config{SkipPaths: []string{"/health"}}))
+package vlhttp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+)
+
+// Message is the message of the request entries
+const Message = "Request completed"
+
+type Config struct {
+	// IDGenerator makes the trace ID of requests that don't carry one; nil means
+	// logger.RandomIDGenerator
+	IDGenerator logger.IDGenerator
+	// TraceHeader, when set, is the request header an incoming trace ID is read from, e.g.
+	// "X-Request-ID"; the trace ID is echoed in the same response header
+	TraceHeader string
+	// SkipPaths are request paths that are not logged, e.g. "/health" or "/metrics"
+	SkipPaths []string
+	// Skip, when set, is consulted for every request; requests it returns true for are not logged
+	Skip func(r *http.Request) bool
+	// Level picks the entry level from the response status; nil means DefaultLevel
+	Level func(status int) logger.LogLevel
+	// Route returns the route pattern the request matched, e.g. "/users/{id}"; nil means
+	// http.Request.Pattern, which net/http's ServeMux sets
+	Route func(r *http.Request) string
+	// Sanitize strips CR/LF, ANSI escapes and control characters from client-controlled values
+	Sanitize bool
+	// Body, when set, also logs request and response bodies; leave it nil in production unless
+	// the bodies are known to be safe to store
+	Body *BodyConfig
+}
+
+// DefaultLevel logs 5xx responses as ERROR, 4xx as WARN and everything else as INFO
+func DefaultLevel(status int) logger.LogLevel {
+	switch {
+	case status >= 500:
+		return logger.ERROR
+	case status >= 400:
+		return logger.WARN
+	default:
+		return logger.INFO
+	}
+}
+
+// Middleware returns net/http middleware logging every request to l with its method, path,
+// route, status, response size and duration. The request context carries a trace ID, kept from
+// the incoming context or TraceHeader or generated, so the handler's own entries share it. A
+// nil config means the zero Config.
+func Middleware(l logger.Logger, config *Config) func(http.Handler) http.Handler {
+	if config == nil {
+		config = &Config{}
+	}
+	ids := config.IDGenerator
+	if ids == nil {
+		ids = logger.RandomIDGenerator{}
+	}
+	level := config.Level
+	if level == nil {
+		level = DefaultLevel
+	}
+	route := config.Route
+	if route == nil {
+		route = func(r *http.Request) string { return r.Pattern }
+	}
+	skipPaths := make(map[string]bool, len(config.SkipPaths))
+	for _, path := range config.SkipPaths {
+		skipPaths[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skipPaths[r.URL.Path] || (config.Skip != nil && config.Skip(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+
+			ctx := r.Context()
+			traceID := logger.TraceIDFromContext(ctx)
+			if traceID == "" && config.TraceHeader != "" {
+				traceID = r.Header.Get(config.TraceHeader)
+				if config.Sanitize {
+					traceID = logger.Sanitize(traceID)
+				}
+			}
+			if traceID == "" {
+				traceID = "trace_" + ids.NewID()
+			}
+			ctx = logger.ContextWithTraceID(ctx, traceID)
+			if config.TraceHeader != "" {
+				w.Header().Set(config.TraceHeader, traceID)
+			}
+
+			rw := &responseWriter{ResponseWriter: w}
+			req := r.WithContext(ctx)
+			var requestBody *bodyCapture
+			if config.Body != nil {
+				rw.body = newBodyCapture(config.Body)
+				if req.Body != nil && req.Body != http.NoBody {
+					requestBody = newBodyCapture(config.Body)
+					req.Body = &captureReader{ReadCloser: req.Body, contentType: req.Header.Get("Content-Type"), capture: requestBody}
+				}
+			}
+			next.ServeHTTP(rw, req)
+
+			fields := map[string]interface{}{
+				"method":     req.Method,
+				"path":       req.URL.Path,
+				"query":      req.URL.RawQuery,
+				"user_agent": req.UserAgent(),
+				"remote_ip":  req.RemoteAddr,
+			}
+			if config.Sanitize {
+				fields = logger.SanitizeFields(fields, logger.DefaultMaxInputLength)
+			}
+			if pattern := route(req); pattern != "" {
+				fields["route"] = pattern
+			}
+			fields["status"] = rw.statusCode()
+			fields["size"] = rw.size
+			fields["duration"] = time.Since(start).Milliseconds()
+			requestBody.addTo(fields, "request_body", config.Sanitize)
+			rw.body.addTo(fields, "response_body", config.Sanitize)
+			logger.LogAt(l, ctx, level(rw.statusCode()), Message, fields)
+		})
+	}
+}
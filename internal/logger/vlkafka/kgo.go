@@ -0,0 +1,91 @@
+package vlkafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// KgoHooks are franz-go client hooks. On produce, they add the trace ID of the Produce context
+// to the record headers and log the record once acknowledged. On consume, they set the
+// record's Context to one carrying the trace ID from its headers and log the record as it is
+// polled.
+type KgoHooks struct {
+	recordLogger
+}
+
+var (
+	_ kgo.HookProduceRecordBuffered   = (*KgoHooks)(nil)
+	_ kgo.HookProduceRecordUnbuffered = (*KgoHooks)(nil)
+	_ kgo.HookFetchRecordBuffered     = (*KgoHooks)(nil)
+	_ kgo.HookFetchRecordUnbuffered   = (*KgoHooks)(nil)
+)
+
+// NewKgoHooks returns hooks logging to l, to pass to kgo.WithHooks; a nil config means the
+// zero Config
+func NewKgoHooks(l logger.Logger, config *Config) *KgoHooks {
+	return &KgoHooks{recordLogger: newRecordLogger(l, config)}
+}
+
+type contextKey int
+
+const produceStartKey contextKey = iota
+
+func (h *KgoHooks) OnProduceRecordBuffered(r *kgo.Record) {
+	ctx := r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if traceID := logger.TraceIDFromContext(ctx); traceID != "" && !hasKgoHeader(r, h.header) {
+		r.Headers = append(r.Headers, kgo.RecordHeader{Key: h.header, Value: []byte(traceID)})
+	}
+	r.Context = context.WithValue(ctx, produceStartKey, time.Now())
+}
+
+func (h *KgoHooks) OnProduceRecordUnbuffered(r *kgo.Record, err error) {
+	ctx := r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var duration time.Duration
+	if start, ok := ctx.Value(produceStartKey).(time.Time); ok {
+		duration = time.Since(start)
+	}
+	h.produced(ctx, r.Topic, r.Partition, r.Offset, duration, err)
+}
+
+func (h *KgoHooks) OnFetchRecordBuffered(r *kgo.Record) {
+	ctx := r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, header := range r.Headers {
+		if header.Key == h.header {
+			ctx = consumeContext(ctx, string(header.Value))
+			break
+		}
+	}
+	r.Context = ctx
+}
+
+func (h *KgoHooks) OnFetchRecordUnbuffered(r *kgo.Record, polled bool) {
+	if !polled {
+		return
+	}
+	ctx := r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	h.consumed(ctx, r.Topic, r.Partition, r.Offset, r.Timestamp)
+}
+
+func hasKgoHeader(r *kgo.Record, key string) bool {
+	for _, header := range r.Headers {
+		if header.Key == key {
+			return true
+		}
+	}
+	return false
+}
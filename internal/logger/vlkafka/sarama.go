@@ -0,0 +1,108 @@
+package vlkafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+)
+
+// SaramaSyncProducer wraps a sarama.SyncProducer to add the trace ID of the context to the
+// message headers and log every message once acknowledged
+type SaramaSyncProducer struct {
+	sarama.SyncProducer
+	recordLogger
+}
+
+// WrapSaramaSyncProducer returns p logging to l; a nil config means the zero Config
+func WrapSaramaSyncProducer(p sarama.SyncProducer, l logger.Logger, config *Config) *SaramaSyncProducer {
+	return &SaramaSyncProducer{SyncProducer: p, recordLogger: newRecordLogger(l, config)}
+}
+
+// SendMessage is SendMessageContext without a trace ID to propagate
+func (p *SaramaSyncProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	return p.SendMessageContext(context.Background(), msg)
+}
+
+// SendMessageContext sends msg with the trace ID of ctx and logs it with ctx
+func (p *SaramaSyncProducer) SendMessageContext(ctx context.Context, msg *sarama.ProducerMessage) (int32, int64, error) {
+	p.inject(ctx, msg)
+	start := time.Now()
+	partition, offset, err := p.SyncProducer.SendMessage(msg)
+	p.produced(ctx, msg.Topic, partition, offset, time.Since(start), err)
+	return partition, offset, err
+}
+
+// SendMessages is SendMessagesContext without a trace ID to propagate
+func (p *SaramaSyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	return p.SendMessagesContext(context.Background(), msgs)
+}
+
+// SendMessagesContext sends msgs with the trace ID of ctx and logs each of them with ctx
+func (p *SaramaSyncProducer) SendMessagesContext(ctx context.Context, msgs []*sarama.ProducerMessage) error {
+	for _, msg := range msgs {
+		p.inject(ctx, msg)
+	}
+	start := time.Now()
+	err := p.SyncProducer.SendMessages(msgs)
+	duration := time.Since(start)
+
+	failed := map[*sarama.ProducerMessage]error{}
+	if errs, ok := err.(sarama.ProducerErrors); ok {
+		for _, e := range errs {
+			failed[e.Msg] = e.Err
+		}
+	}
+	for _, msg := range msgs {
+		msgErr, ok := failed[msg]
+		if !ok && len(failed) == 0 {
+			// Not a per-message error, so it applies to all of them
+			msgErr = err
+		}
+		p.produced(ctx, msg.Topic, msg.Partition, msg.Offset, duration, msgErr)
+	}
+	return err
+}
+
+func (p *SaramaSyncProducer) inject(ctx context.Context, msg *sarama.ProducerMessage) {
+	traceID := logger.TraceIDFromContext(ctx)
+	if traceID == "" {
+		return
+	}
+	for _, header := range msg.Headers {
+		if string(header.Key) == p.header {
+			return
+		}
+	}
+	msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(p.header), Value: []byte(traceID)})
+}
+
+// SaramaConsumerInterceptor is a sarama.ConsumerInterceptor logging every consumed message
+// with the trace ID from its headers. Register it in Config.Consumer.Interceptors.
+type SaramaConsumerInterceptor struct {
+	recordLogger
+}
+
+var _ sarama.ConsumerInterceptor = (*SaramaConsumerInterceptor)(nil)
+
+// NewSaramaConsumerInterceptor returns an interceptor logging to l; a nil config means the zero Config
+func NewSaramaConsumerInterceptor(l logger.Logger, config *Config) *SaramaConsumerInterceptor {
+	return &SaramaConsumerInterceptor{recordLogger: newRecordLogger(l, config)}
+}
+
+func (i *SaramaConsumerInterceptor) OnConsume(msg *sarama.ConsumerMessage) {
+	ctx := i.MessageContext(context.Background(), msg)
+	i.consumed(ctx, msg.Topic, msg.Partition, msg.Offset, msg.Timestamp)
+}
+
+// MessageContext returns ctx carrying the trace ID from msg's headers, for the handler
+// processing msg to log with
+func (i *SaramaConsumerInterceptor) MessageContext(ctx context.Context, msg *sarama.ConsumerMessage) context.Context {
+	for _, header := range msg.Headers {
+		if header != nil && string(header.Key) == i.header {
+			return consumeContext(ctx, string(header.Value))
+		}
+	}
+	return ctx
+}
@@ -0,0 +1,90 @@
+// Package vlkafka logs the records produced and consumed with franz-go or sarama, with their
+// topic, partition, offset and latency, and carries the trace ID from producer to consumer in
+// a record header.
+//
+//	client, err := kgo.NewClient(kgo.SeedBrokers(brokers...), kgo.WithHooks(vlkafka.NewKgoHooks(vlLogger, nil)))
+package vlkafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+)
+
+// DefaultTraceHeader is the record header carrying the trace ID when Config.TraceHeader is empty
+const DefaultTraceHeader = "trace_id"
+
+// Messages of the record entries
+const (
+	ProducedMessage      = "Kafka record produced"
+	ProduceFailedMessage = "Kafka record produce failed"
+	ConsumedMessage      = "Kafka record consumed"
+)
+
+type Config struct {
+	// TraceHeader is the record header the trace ID is written to and read from; empty means
+	// DefaultTraceHeader
+	TraceHeader string
+	// Level is the level of produced and consumed records; the zero value is DEBUG. Failed
+	// produces are logged as ERROR.
+	Level logger.LogLevel
+}
+
+// recordLogger holds what the franz-go and sarama integrations share
+type recordLogger struct {
+	logger logger.Logger
+	header string
+	level  logger.LogLevel
+}
+
+func newRecordLogger(l logger.Logger, config *Config) recordLogger {
+	r := recordLogger{logger: l, header: DefaultTraceHeader}
+	if config != nil {
+		if config.TraceHeader != "" {
+			r.header = config.TraceHeader
+		}
+		r.level = config.Level
+	}
+	return r
+}
+
+// consumeContext returns ctx carrying traceID, when the record had one
+func consumeContext(ctx context.Context, traceID string) context.Context {
+	if traceID == "" {
+		return ctx
+	}
+	return logger.ContextWithTraceID(ctx, logger.Sanitize(traceID))
+}
+
+// produced logs a produce once the broker acknowledged or rejected it, duration being the time
+// from Produce to the acknowledgement
+func (r recordLogger) produced(ctx context.Context, topic string, partition int32, offset int64, duration time.Duration, err error) {
+	fields := map[string]interface{}{
+		"topic":     topic,
+		"partition": partition,
+	}
+	if duration > 0 {
+		fields["duration"] = duration.Milliseconds()
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+		logger.LogAt(r.logger, ctx, logger.ERROR, ProduceFailedMessage, fields)
+		return
+	}
+	fields["offset"] = offset
+	logger.LogAt(r.logger, ctx, r.level, ProducedMessage, fields)
+}
+
+// consumed logs a consumed record, lag being the time since it was produced
+func (r recordLogger) consumed(ctx context.Context, topic string, partition int32, offset int64, timestamp time.Time) {
+	fields := map[string]interface{}{
+		"topic":     topic,
+		"partition": partition,
+		"offset":    offset,
+	}
+	if !timestamp.IsZero() {
+		fields["lag"] = time.Since(timestamp).Milliseconds()
+	}
+	logger.LogAt(r.logger, ctx, r.level, ConsumedMessage, fields)
+}
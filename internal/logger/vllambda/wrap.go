@@ -0,0 +1,116 @@
+// Package vllambda wraps AWS Lambda handlers to log each invocation and flush the logger before
+// it returns, as the sandbox may be frozen right after, with entries still in the buffer.
+//
+//	lambda.Start(vllambda.Wrap(vlLogger, handler))
+package vllambda
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// DefaultFlushTimeout bounds the flush of invocations without a deadline
+const DefaultFlushTimeout = 5 * time.Second
+
+// Fields of the invocation entries and of Extractor
+const (
+	RequestIDKey = "request_id"
+	FunctionKey  = "function"
+	ColdStartKey = "cold_start"
+)
+
+// invoked is set by the first invocation of the process, which is the cold start
+var invoked atomic.Bool
+
+// Wrap returns handler logging the start and end of every invocation to l, with its duration
+// and whether it was a cold start, and flushing l before returning, also when the handler
+// panics. The context passed to handler carries the Lambda request ID as trace ID, unless it
+// already has one.
+func Wrap[TIn, TOut any](l logger.Logger, handler func(context.Context, TIn) (TOut, error)) func(context.Context, TIn) (TOut, error) {
+	return func(ctx context.Context, in TIn) (out TOut, err error) {
+		ctx, done := invocation(ctx, l)
+		defer func() { done(err, recover()) }()
+		return handler(ctx, in)
+	}
+}
+
+// WrapHandler is Wrap for a lambda.Handler, e.g. one built with lambda.NewHandler
+func WrapHandler(l logger.Logger, handler lambda.Handler) lambda.Handler {
+	return handlerFunc(Wrap(l, handler.Invoke))
+}
+
+type handlerFunc func(ctx context.Context, payload []byte) ([]byte, error)
+
+func (f handlerFunc) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	return f(ctx, payload)
+}
+
+// invocation logs the start of an invocation and returns its context and the function that
+// logs its end and flushes, re-panicking with the recovered value if there was one
+func invocation(ctx context.Context, l logger.Logger) (context.Context, func(err error, recovered interface{})) {
+	start := time.Now()
+	coldStart := !invoked.Swap(true)
+	if lc, ok := lambdacontext.FromContext(ctx); ok && logger.TraceIDFromContext(ctx) == "" {
+		ctx = logger.ContextWithTraceID(ctx, lc.AwsRequestID)
+	}
+	fields := Extractor(ctx)
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	fields[ColdStartKey] = coldStart
+	l.Info(ctx, "Invocation started", fields)
+
+	return ctx, func(err error, recovered interface{}) {
+		end := make(map[string]interface{}, len(fields)+2)
+		for k, val := range fields {
+			end[k] = val
+		}
+		end["duration"] = time.Since(start).Milliseconds()
+		switch {
+		case recovered != nil:
+			end["panic"] = fmt.Sprint(recovered)
+			l.Error(ctx, "Invocation panicked", end)
+		case err != nil:
+			end["error"] = err.Error()
+			l.Error(ctx, "Invocation failed", end)
+		default:
+			l.Info(ctx, "Invocation completed", end)
+		}
+		flush(ctx, l)
+		if recovered != nil {
+			panic(recovered)
+		}
+	}
+}
+
+// flush delivers the buffered entries before the invocation deadline, even when ctx was
+// cancelled
+func flush(ctx context.Context, l logger.Logger) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultFlushTimeout)
+	}
+	flushCtx, cancel := context.WithDeadline(context.WithoutCancel(ctx), deadline)
+	defer cancel()
+	_ = l.Flush(flushCtx)
+}
+
+// Extractor is a logger.ContextExtractor adding the Lambda request ID and function name to
+// every entry logged with an invocation context; add it to Config.ContextExtractors
+func Extractor(ctx context.Context) map[string]interface{} {
+	lc, ok := lambdacontext.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	fields := map[string]interface{}{RequestIDKey: lc.AwsRequestID}
+	if lambdacontext.FunctionName != "" {
+		fields[FunctionKey] = lambdacontext.FunctionName
+	}
+	return fields
+}
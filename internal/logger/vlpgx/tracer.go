@@ -0,0 +1,152 @@
+// Package vlpgx logs the queries and batches run by pgx, with their duration and outcome, on
+// the context of the call so entries carry its trace_id.
+//
+//	config, err := pgxpool.ParseConfig(dsn)
+//	config.ConnConfig.Tracer = vlpgx.NewTracer(vlLogger, nil)
+package vlpgx
+
+import (
+	"context"
+	"time"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+	"github.com/jackc/pgx/v5"
+)
+
+type Config struct {
+	// Level is the level of successful queries and batches; the zero value is DEBUG. Failed
+	// ones are logged as ERROR and slow ones as WARN.
+	Level logger.LogLevel
+	// SlowThreshold marks slower queries and batches as slow; zero turns it off
+	SlowThreshold time.Duration
+	// LogStart also logs an entry, at Level, when a query or batch starts
+	LogStart bool
+	// LogArgs adds the query arguments as args; they are left out by default as they often
+	// carry user data
+	LogArgs bool
+}
+
+// Tracer implements pgx.QueryTracer and pgx.BatchTracer
+type Tracer struct {
+	logger logger.Logger
+	config Config
+}
+
+var (
+	_ pgx.QueryTracer = (*Tracer)(nil)
+	_ pgx.BatchTracer = (*Tracer)(nil)
+)
+
+// NewTracer returns a tracer logging to l; a nil config means the zero Config
+func NewTracer(l logger.Logger, config *Config) *Tracer {
+	t := &Tracer{logger: l}
+	if config != nil {
+		t.config = *config
+	}
+	return t
+}
+
+type contextKey int
+
+const (
+	queryKey contextKey = iota
+	batchKey
+)
+
+// traceData is what the start of a query or batch leaves in the context for its end
+type traceData struct {
+	start time.Time
+	sql   string
+	args  []any
+	// queries is the size of a batch
+	queries int
+}
+
+func (t *Tracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if t.config.LogStart {
+		fields := t.fields(conn, data.SQL, data.Args)
+		logger.LogAt(t.logger, ctx, t.config.Level, "SQL query started", fields)
+	}
+	return context.WithValue(ctx, queryKey, &traceData{start: time.Now(), sql: data.SQL, args: data.Args})
+}
+
+func (t *Tracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	td, ok := ctx.Value(queryKey).(*traceData)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(td.start)
+	fields := t.fields(conn, td.sql, td.args)
+	fields["duration"] = elapsed.Milliseconds()
+	if data.Err == nil {
+		fields["command_tag"] = data.CommandTag.String()
+		fields["rows_affected"] = data.CommandTag.RowsAffected()
+	}
+	t.log(ctx, "SQL query", elapsed, data.Err, fields)
+}
+
+func (t *Tracer) TraceBatchStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	td := &traceData{start: time.Now()}
+	if data.Batch != nil {
+		td.queries = data.Batch.Len()
+	}
+	if t.config.LogStart {
+		fields := t.fields(conn, "", nil)
+		fields["queries"] = td.queries
+		logger.LogAt(t.logger, ctx, t.config.Level, "SQL batch started", fields)
+	}
+	return context.WithValue(ctx, batchKey, td)
+}
+
+// TraceBatchQuery logs each query of a batch as its result is read; the batch's duration is
+// logged by TraceBatchEnd
+func (t *Tracer) TraceBatchQuery(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchQueryData) {
+	fields := t.fields(conn, data.SQL, data.Args)
+	if data.Err == nil {
+		fields["command_tag"] = data.CommandTag.String()
+		fields["rows_affected"] = data.CommandTag.RowsAffected()
+	}
+	t.log(ctx, "SQL batch query", 0, data.Err, fields)
+}
+
+func (t *Tracer) TraceBatchEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchEndData) {
+	td, ok := ctx.Value(batchKey).(*traceData)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(td.start)
+	fields := t.fields(conn, "", nil)
+	fields["queries"] = td.queries
+	fields["duration"] = elapsed.Milliseconds()
+	t.log(ctx, "SQL batch", elapsed, data.Err, fields)
+}
+
+// fields returns the fields shared by every entry; sql is left out when empty
+func (t *Tracer) fields(conn *pgx.Conn, sql string, args []any) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if sql != "" {
+		fields["sql"] = sql
+	}
+	if t.config.LogArgs && len(args) > 0 {
+		fields["args"] = args
+	}
+	if conn != nil {
+		fields["db"] = conn.Config().Database
+	}
+	return fields
+}
+
+// log writes a finished query or batch as ERROR when it failed, WARN when slow and at Level
+// otherwise
+func (t *Tracer) log(ctx context.Context, msg string, elapsed time.Duration, err error, fields map[string]interface{}) {
+	level := t.config.Level
+	switch {
+	case err != nil:
+		level, msg = logger.ERROR, msg+" failed"
+		fields["error"] = err.Error()
+	case t.config.SlowThreshold > 0 && elapsed > t.config.SlowThreshold:
+		level, msg = logger.WARN, "Slow "+msg
+		fields["slow_threshold"] = t.config.SlowThreshold.Milliseconds()
+	}
+	logger.LogAt(t.logger, ctx, level, msg, fields)
+}
@@ -0,0 +1,298 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// batchJob is a batch handed to a sender. errs collects its delivery error when a Flush
+// waits for it and is nil otherwise.
+type batchJob struct {
+	entries []LogEntry
+	errs    *errorList
+}
+
+// errorList gathers errors from concurrent senders
+type errorList struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (l *errorList) add(err error) {
+	if l == nil || err == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errs = append(l.errs, err)
+}
+
+func (l *errorList) err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return errors.Join(l.errs...)
+}
+
+// batcher collects buffered entries into batches of BatchSize, or whatever arrived by the
+// next FlushInterval tick, and hands them to Config.Workers senders
+type batcher struct {
+	v     *VictoriaLogsLogger
+	batch []LogEntry
+	// seen is the newest disk queue entry received
+	seen     uint64
+	jobs     chan batchJob
+	inflight sync.WaitGroup
+	// ticker fires every interval; received counts entries since the last tick
+	ticker   Ticker
+	interval time.Duration
+	received int
+}
+
+func (v *VictoriaLogsLogger) startAsyncProcessing() {
+	if v.queue != nil {
+		v.startQueueReader()
+	}
+	b := &batcher{v: v, batch: v.NewLoggerEntryBatch(), jobs: make(chan batchJob, v.cfg().workers())}
+	for i := 0; i < v.cfg().workers(); i++ {
+		v.life.wg.Add(1)
+		go func() {
+			defer v.life.wg.Done()
+			b.send()
+		}()
+	}
+	v.life.wg.Add(1)
+	go func() {
+		defer v.life.wg.Done()
+		b.run()
+	}()
+}
+
+func (b *batcher) run() {
+	v := b.v
+	b.interval = b.initialInterval()
+	b.ticker = v.cfg().clock().NewTicker(b.interval)
+	defer func() { b.ticker.Stop() }()
+	// Senders stop once the jobs handed out at shutdown are done
+	defer close(b.jobs)
+
+	for {
+		// While the health probe finds VictoriaLogs down, entries stay in the buffer or disk queue
+		if v.life.paused.Load() {
+			select {
+			case <-v.life.pauseChanged:
+			case done := <-v.flushReq:
+				done <- b.flush()
+			case <-b.ticker.C():
+				v.reportSuppressed()
+				v.flushDedup(false)
+			case <-v.life.ctx.Done():
+				b.shutdown()
+				return
+			}
+			continue
+		}
+		// Under load, ERROR and above are batched before anything else
+		select {
+		case entry := <-v.priority:
+			b.add(entry, nil)
+			continue
+		default:
+		}
+		select {
+		case entry := <-v.priority:
+			b.add(entry, nil)
+		case entry := <-v.buffer:
+			b.add(entry, nil)
+		case done := <-v.flushReq:
+			done <- b.flush()
+		case <-v.life.pauseChanged:
+		case <-v.life.reloaded:
+			b.setInterval(b.initialInterval())
+		case <-b.ticker.C():
+			v.reportSuppressed()
+			v.flushDedup(false)
+			b.dispatch(nil)
+			b.adapt()
+		case <-v.life.ctx.Done():
+			b.shutdown()
+			return
+		}
+	}
+}
+
+// shutdown sends what is left when the logger context ends, or abandons it once Close gave up
+func (b *batcher) shutdown() {
+	v := b.v
+	_ = b.flush()
+	for drained := false; !drained; {
+		select {
+		case entry := <-v.priority:
+			b.batch = append(b.batch, entry)
+		case entry := <-v.buffer:
+			b.batch = append(b.batch, entry)
+		default:
+			drained = true
+		}
+	}
+	v.abandon(b.batch)
+}
+
+// send delivers batches until the jobs channel is closed. Once Close gave up waiting,
+// remaining batches are abandoned instead.
+func (b *batcher) send() {
+	for job := range b.jobs {
+		if b.v.life.drainExpired.Load() {
+			b.v.abandon(job.entries)
+		} else {
+			job.errs.add(b.v.sendBatch(job.entries, false))
+		}
+		b.inflight.Done()
+	}
+}
+
+// add hands the batch to a sender as soon as it reaches BatchSize; smaller batches wait for the ticker
+func (b *batcher) add(entry LogEntry, errs *errorList) {
+	b.batch = append(b.batch, entry)
+	b.seen = max(b.seen, entry.seq)
+	b.received++
+	// The first entry after an idle stretch should not wait out a long interval
+	if b.received == 1 && b.v.cfg().adaptiveFlush() && b.interval > b.v.cfg().MinFlushInterval {
+		b.setInterval(b.v.cfg().MinFlushInterval)
+	}
+	if len(b.batch) >= b.v.cfg().BatchSize {
+		b.dispatch(errs)
+	}
+}
+
+func (b *batcher) dispatch(errs *errorList) {
+	if len(b.batch) == 0 {
+		return
+	}
+	b.inflight.Add(1)
+	b.jobs <- batchJob{entries: b.batch, errs: errs}
+	b.batch = b.v.NewLoggerEntryBatch()
+}
+
+// initialInterval is FlushInterval, within MinFlushInterval and MaxFlushInterval when they are set
+func (b *batcher) initialInterval() time.Duration {
+	c := b.v.cfg()
+	if c.adaptiveFlush() {
+		return min(max(c.FlushInterval, c.MinFlushInterval), c.MaxFlushInterval)
+	}
+	return c.FlushInterval
+}
+
+// adapt halves the flush interval after a tick with traffic and doubles it after an idle one,
+// within MinFlushInterval and MaxFlushInterval
+func (b *batcher) adapt() {
+	c := b.v.cfg()
+	received := b.received
+	b.received = 0
+	if !c.adaptiveFlush() {
+		return
+	}
+	if received > 0 {
+		b.setInterval(max(b.interval/2, c.MinFlushInterval))
+	} else {
+		b.setInterval(min(b.interval*2, c.MaxFlushInterval))
+	}
+}
+
+func (b *batcher) setInterval(d time.Duration) {
+	if d == b.interval {
+		return
+	}
+	b.ticker.Stop()
+	b.interval = d
+	b.ticker = b.v.cfg().clock().NewTicker(d)
+}
+
+// flush sends the buffer and the pending batch and waits for every batch in flight, stopping
+// early once Close gave up. It returns the delivery errors of the batches it sent.
+func (b *batcher) flush() error {
+	v := b.v
+	errs := &errorList{}
+	for drained := false; !drained && !v.life.drainExpired.Load(); {
+		select {
+		case entry := <-v.priority:
+			b.add(entry, errs)
+			continue
+		default:
+		}
+		select {
+		case entry := <-v.buffer:
+			b.add(entry, errs)
+		default:
+			drained = true
+		}
+	}
+	// Entries still on disk are on their way through the buffer
+	for target := v.queue.appended(); b.seen < target && v.life.ctx.Err() == nil; {
+		select {
+		case entry := <-v.buffer:
+			b.add(entry, errs)
+		case <-v.life.ctx.Done():
+		}
+	}
+	if !v.life.drainExpired.Load() {
+		b.dispatch(errs)
+	}
+	b.inflight.Wait()
+	return errs.err()
+}
+
+// offer buffers entry without blocking and reports whether there was room. ERROR and above
+// go to the priority buffer first and to the regular one when it is full, so when the
+// pipeline is congested lower levels are shed first.
+func (v *VictoriaLogsLogger) offer(entry LogEntry) bool {
+	if entry.Level >= ERROR {
+		select {
+		case v.priority <- entry:
+			return true
+		default:
+		}
+	}
+	select {
+	case v.buffer <- entry:
+		return true
+	default:
+		return false
+	}
+}
+
+// offerWait is offer waiting for room in the buffer until ctx ends or the logger is closed
+func (v *VictoriaLogsLogger) offerWait(ctx context.Context, entry LogEntry) error {
+	if v.offer(entry) {
+		return nil
+	}
+	select {
+	case v.buffer <- entry:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-v.life.ctx.Done():
+		return ErrClosed
+	}
+}
+
+// startQueueReader moves entries from the disk queue into the buffer as the worker makes room.
+// Entries still on disk at Close stay there and are sent after the next start.
+func (v *VictoriaLogsLogger) startQueueReader() {
+	v.life.wg.Add(1)
+	go func() {
+		defer v.life.wg.Done()
+		for {
+			entry, ok := v.queue.next(v.life.ctx.Done())
+			if !ok {
+				return
+			}
+			select {
+			case v.buffer <- entry:
+			case <-v.life.ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// maxWriterLine is the longest partial line kept by a Writer before it is logged without a newline
+const maxWriterLine = 64 * 1024
+
+// Writer returns an io.Writer that logs every line written to it as an entry at level.
+// It can be passed to log.SetOutput, http.Server.ErrorLog and other io.Writer based APIs:
+//
+//	srv.ErrorLog = log.New(vlLogger.Writer(logger.ERROR), "", 0)
+func (v *VictoriaLogsLogger) Writer(level LogLevel) io.Writer {
+	return &levelWriter{logger: v, level: level}
+}
+
+type levelWriter struct {
+	logger *VictoriaLogsLogger
+	level  LogLevel
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *levelWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) > maxWriterLine {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+func (w *levelWriter) emit(line []byte) {
+	line = bytes.TrimRight(line, "\r")
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+	w.logger.log(context.Background(), w.level, string(line), nil)
+}
@@ -0,0 +1,97 @@
+// Package zapvl lets zap loggers write to VictoriaLogs through a zapcore.Core.
+//
+//	core := zapcore.NewTee(existingCore, zapvl.NewCore(vlLogger, zapcore.InfoLevel))
+//	zapLogger := zap.New(core)
+package zapvl
+
+import (
+	"context"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// Core is a zapcore.Core backed by a VictoriaLogsLogger
+type Core struct {
+	zapcore.LevelEnabler
+	logger *logger.VictoriaLogsLogger
+	fields map[string]interface{}
+}
+
+// NewCore returns a Core writing entries enabled by enab to l
+func NewCore(l *logger.VictoriaLogsLogger, enab zapcore.LevelEnabler) *Core {
+	return &Core{
+		LevelEnabler: enab,
+		logger:       l,
+		fields:       map[string]interface{}{},
+	}
+}
+
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	clone := &Core{
+		LevelEnabler: c.LevelEnabler,
+		logger:       c.logger,
+		fields:       encodeFields(c.fields, fields),
+	}
+	return clone
+}
+
+func (c *Core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *Core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	merged := encodeFields(c.fields, fields)
+	if ent.LoggerName != "" {
+		merged["logger"] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		merged["caller"] = ent.Caller.TrimmedPath()
+		merged["func"] = ent.Caller.Function
+	}
+	if ent.Stack != "" {
+		merged["stack"] = ent.Stack
+	}
+
+	// zap itself exits or panics after Write for its Fatal and Panic levels,
+	// so the entry is only logged here, never acted upon
+	c.logger.Log(context.Background(), Level(ent.Level), ent.Message, merged)
+	return nil
+}
+
+func (c *Core) Sync() error {
+	return c.logger.Flush(context.Background())
+}
+
+// Level maps a zap level onto the closest logger level
+func Level(l zapcore.Level) logger.LogLevel {
+	switch {
+	case l <= zapcore.DebugLevel:
+		return logger.DEBUG
+	case l == zapcore.InfoLevel:
+		return logger.INFO
+	case l == zapcore.WarnLevel:
+		return logger.WARN
+	case l == zapcore.ErrorLevel || l == zapcore.DPanicLevel:
+		return logger.ERROR
+	case l == zapcore.PanicLevel:
+		return logger.PANIC
+	default:
+		return logger.FATAL
+	}
+}
+
+// encodeFields returns a new map holding base plus fields rendered by zap's map encoder
+func encodeFields(base map[string]interface{}, fields []zapcore.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for k, v := range base {
+		enc.Fields[k] = v
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
@@ -0,0 +1,142 @@
+// Package query reads logs back from VictoriaLogs with LogsQL, for tooling and tests
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/anhdnyopaz/go_victorialog/internal/logger"
+)
+
+// maxErrorBody caps how much of a rejected response body is kept in the returned error
+const maxErrorBody = 1024
+
+type Config struct {
+	// URL is the VictoriaLogs base URL, e.g. "http://localhost:9428"; an insert or select URL
+	// of the same instance works too
+	URL string `yaml:"url"`
+	// Timeout bounds each request except Tail; zero means no timeout beyond the context
+	Timeout time.Duration `yaml:"timeout"`
+	// Tenant is sent as the AccountID/ProjectID headers
+	Tenant logger.Tenant `yaml:"tenant"`
+	// BasicAuth or BearerToken authenticate every request; at most one may be set
+	BasicAuth   *logger.BasicAuth `yaml:"basic_auth"`
+	BearerToken string            `yaml:"bearer_token"`
+	// Headers are added to every request
+	Headers map[string]string `yaml:"headers"`
+	// HTTPClient sends the requests; nil means http.DefaultClient
+	HTTPClient *http.Client `yaml:"-"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		URL:     "http://localhost:9428",
+		Timeout: 30 * time.Second,
+	}
+}
+
+// Client runs LogsQL queries against VictoriaLogs
+type Client struct {
+	config  *Config
+	baseURL *url.URL
+	client  *http.Client
+}
+
+// NewClient creates a client; a nil config means DefaultConfig
+func NewClient(config *Config) (*Client, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if config.BasicAuth != nil && config.BearerToken != "" {
+		return nil, errors.New("BasicAuth and BearerToken are mutually exclusive")
+	}
+	base, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VictoriaLogs URL: %w", err)
+	}
+	if base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid VictoriaLogs URL %q", config.URL)
+	}
+	// Keep a path prefix from a reverse proxy but not the endpoint itself
+	for _, endpoint := range []string{"/insert/", "/select/", "/delete/"} {
+		if i := strings.Index(base.Path, endpoint); i >= 0 {
+			base.Path = base.Path[:i]
+		}
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/")
+	base.RawQuery = ""
+
+	client := config.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{config: config, baseURL: base, client: client}, nil
+}
+
+// requestContext bounds ctx by Config.Timeout
+func (c *Client) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.config.Timeout > 0 {
+		return context.WithTimeout(ctx, c.config.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// post sends form values to the endpoint path, e.g. "/select/logsql/query", and returns the
+// response when its status is 200; otherwise the error is a *logger.HTTPError
+func (c *Client) post(ctx context.Context, path string, form url.Values) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for name, value := range c.config.Headers {
+		req.Header.Set(name, value)
+	}
+	switch {
+	case c.config.BasicAuth != nil:
+		req.SetBasicAuth(c.config.BasicAuth.Username, c.config.BasicAuth.Password)
+	case c.config.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+c.config.BearerToken)
+	}
+	if c.config.Tenant.AccountID != "" {
+		req.Header.Set("AccountID", c.config.Tenant.AccountID)
+	}
+	if c.config.Tenant.ProjectID != "" {
+		req.Header.Set("ProjectID", c.config.Tenant.ProjectID)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, &logger.HTTPError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+	return resp, nil
+}
+
+// getJSON posts form to path and decodes the JSON response into out
+func (c *Client) getJSON(ctx context.Context, path string, form url.Values, out interface{}) error {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+	resp, err := c.post(ctx, path, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s response: %w", path, err)
+	}
+	return nil
+}
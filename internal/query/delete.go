@@ -0,0 +1,36 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Delete starts a VictoriaLogs delete task purging every log matching filter, e.g.
+// `user_id:42` for an erasure request, and returns its task ID. Deletion runs in the
+// background on the server; StopDelete cancels it. An empty filter is rejected, pass "*" to
+// delete everything in the tenant.
+func (c *Client) Delete(ctx context.Context, filter string) (string, error) {
+	if strings.TrimSpace(filter) == "" {
+		return "", errors.New("delete filter must not be empty")
+	}
+	var resp struct {
+		TaskID string `json:"task_id"`
+	}
+	if err := c.getJSON(ctx, "/delete/run_task", url.Values{"filter": {filter}}, &resp); err != nil {
+		return "", err
+	}
+	return resp.TaskID, nil
+}
+
+// StopDelete cancels the delete task started by Delete; logs already deleted stay deleted
+func (c *Client) StopDelete(ctx context.Context, taskID string) error {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+	resp, err := c.post(ctx, "/delete/stop_task", url.Values{"task_id": {taskID}})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
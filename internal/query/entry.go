@@ -0,0 +1,67 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Entry is one log returned by VictoriaLogs. VictoriaLogs stores every value as a string, so
+// Fields holds them as such, e.g. "level", "service" or "fields.user_id".
+type Entry struct {
+	Time   time.Time
+	Msg    string
+	Stream string
+	// Fields holds every field except _time, _msg and _stream
+	Fields map[string]string
+}
+
+// Get returns the field name, including _msg, _time and _stream, or "" when it is absent
+func (e Entry) Get(name string) string {
+	switch name {
+	case "_msg":
+		return e.Msg
+	case "_stream":
+		return e.Stream
+	case "_time":
+		if e.Time.IsZero() {
+			return ""
+		}
+		return e.Time.Format(time.RFC3339Nano)
+	}
+	return e.Fields[name]
+}
+
+// Level returns the level field written by the logger, e.g. "ERROR"
+func (e Entry) Level() string {
+	return e.Fields["level"]
+}
+
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*e = Entry{Fields: make(map[string]string, len(raw))}
+	for name, value := range raw {
+		s, ok := value.(string)
+		if !ok {
+			s = fmt.Sprint(value)
+		}
+		switch name {
+		case "_msg":
+			e.Msg = s
+		case "_stream":
+			e.Stream = s
+		case "_time":
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return fmt.Errorf("invalid _time %q: %w", s, err)
+			}
+			e.Time = t
+		default:
+			e.Fields[name] = s
+		}
+	}
+	return nil
+}
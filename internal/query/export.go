@@ -0,0 +1,120 @@
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Export formats
+const (
+	// ExportNDJSON writes one JSON object per log, as returned by VictoriaLogs
+	ExportNDJSON = "ndjson"
+	// ExportCSV writes a header row followed by one row per log
+	ExportCSV = "csv"
+)
+
+// WithFields restricts the fields of each log to fields, in that order for CSV columns
+func WithFields(fields ...string) Option {
+	return func(p *params) { p.fields = fields }
+}
+
+// Export streams every log matching logsQL to w in format, ExportNDJSON or ExportCSV, reading
+// the response as it arrives so that large extracts are never held in memory. WithTimeRange,
+// WithLimit and WithFields apply. Without WithFields, CSV columns are the fields reported by
+// FieldNames for logsQL, _time, _stream and _msg first. Config.Timeout bounds the whole export.
+func (c *Client) Export(ctx context.Context, logsQL string, w io.Writer, format string, opts ...Option) error {
+	if format != ExportNDJSON && format != ExportCSV {
+		return fmt.Errorf("unknown export format %q", format)
+	}
+	p := newParams(opts)
+	columns := p.fields
+	if format == ExportCSV && len(columns) == 0 {
+		names, err := c.FieldNames(ctx, logsQL, opts...)
+		if err != nil {
+			return fmt.Errorf("list fields: %w", err)
+		}
+		columns = csvColumns(names)
+	}
+
+	q := logsQL
+	if len(p.fields) > 0 {
+		q += " | fields " + strings.Join(p.fields, ", ")
+	}
+	if p.limit > 0 {
+		q += " | limit " + strconv.Itoa(p.limit)
+	}
+	form := p.form()
+	form.Set("query", q)
+
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+	resp, err := c.post(ctx, "/select/logsql/query", form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var cw *csv.Writer
+	if format == ExportCSV {
+		cw = csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+	record := make([]string, len(columns))
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if cw == nil {
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return err
+			}
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("decode entry: %w", err)
+		}
+		for i, column := range columns {
+			record[i] = entry.Get(column)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if cw != nil {
+		cw.Flush()
+		return cw.Error()
+	}
+	return nil
+}
+
+// csvColumns orders field names as _time, _stream, _msg, then the others alphabetically
+func csvColumns(names []FieldValue) []string {
+	columns := []string{"_time", "_stream", "_msg"}
+	var rest []string
+	for _, name := range names {
+		switch name.Value {
+		case "_time", "_stream", "_msg", "_stream_id":
+		default:
+			rest = append(rest, name.Value)
+		}
+	}
+	sort.Strings(rest)
+	return append(columns, rest...)
+}
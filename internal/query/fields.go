@@ -0,0 +1,52 @@
+package query
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// FieldValue is a field name or value with the number of matching logs that have it
+type FieldValue struct {
+	Value string `json:"value"`
+	Hits  uint64 `json:"hits"`
+}
+
+// FieldNames lists the fields present in the logs matching filter ("" means all logs) with
+// /select/logsql/field_names, e.g. to autocomplete field names. WithTimeRange applies.
+func (c *Client) FieldNames(ctx context.Context, filter string, opts ...Option) ([]FieldValue, error) {
+	p := newParams(opts)
+	form := p.form()
+	form.Set("query", filterOrAll(filter))
+	return c.fieldValues(ctx, "/select/logsql/field_names", form)
+}
+
+// FieldValues lists the values of field in the logs matching filter ("" means all logs) with
+// /select/logsql/field_values. WithTimeRange applies and WithLimit caps the number of values.
+func (c *Client) FieldValues(ctx context.Context, field, filter string, opts ...Option) ([]FieldValue, error) {
+	p := newParams(opts)
+	form := p.form()
+	form.Set("query", filterOrAll(filter))
+	form.Set("field", field)
+	if p.limit > 0 {
+		form.Set("limit", strconv.Itoa(p.limit))
+	}
+	return c.fieldValues(ctx, "/select/logsql/field_values", form)
+}
+
+func (c *Client) fieldValues(ctx context.Context, path string, form url.Values) ([]FieldValue, error) {
+	var resp struct {
+		Values []FieldValue `json:"values"`
+	}
+	if err := c.getJSON(ctx, path, form, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Values, nil
+}
+
+func filterOrAll(filter string) string {
+	if filter == "" {
+		return "*"
+	}
+	return filter
+}
@@ -0,0 +1,225 @@
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPageSize = 1000
+	// maxLineSize caps a single log line in a response
+	maxLineSize = 16 << 20
+)
+
+// Option adjusts a query
+type Option func(*params)
+
+type params struct {
+	limit       int
+	pageSize    int
+	start       time.Time
+	end         time.Time
+	startOffset time.Duration
+	groupBy     []string
+	fields      []string
+}
+
+// WithLimit stops after n entries in total; zero means all matching entries
+func WithLimit(n int) Option {
+	return func(p *params) { p.limit = n }
+}
+
+// WithPageSize sets how many entries each request fetches (default 1000)
+func WithPageSize(n int) Option {
+	return func(p *params) { p.pageSize = n }
+}
+
+// WithTimeRange restricts the query to logs with start <= _time < end; a zero bound is open
+func WithTimeRange(start, end time.Time) Option {
+	return func(p *params) {
+		p.start = start
+		p.end = end
+	}
+}
+
+func newParams(opts []Option) params {
+	var p params
+	for _, opt := range opts {
+		opt(&p)
+	}
+	if p.pageSize <= 0 {
+		p.pageSize = defaultPageSize
+	}
+	return p
+}
+
+// form returns the time range arguments shared by the select endpoints
+func (p params) form() url.Values {
+	form := url.Values{}
+	if !p.start.IsZero() {
+		form.Set("start", p.start.Format(time.RFC3339Nano))
+	}
+	if !p.end.IsZero() {
+		form.Set("end", p.end.Format(time.RFC3339Nano))
+	}
+	return form
+}
+
+// formatDuration writes d in milliseconds, which VictoriaLogs parses unlike Go's "1m0s"
+func formatDuration(d time.Duration) string {
+	return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+}
+
+// Query runs logsQL on /select/logsql/query and returns an iterator over the matching
+// entries, newest first. Entries are fetched a page at a time as the iterator advances; when
+// no end is given it is fixed to the current time so that pages don't shift as logs arrive.
+//
+//	it := client.Query(ctx, `level:ERROR`, query.WithLimit(100))
+//	defer it.Close()
+//	for it.Next() {
+//		fmt.Println(it.Entry().Msg)
+//	}
+//	if err := it.Err(); err != nil { ... }
+func (c *Client) Query(ctx context.Context, logsQL string, opts ...Option) *Iterator {
+	p := newParams(opts)
+	if p.end.IsZero() {
+		p.end = time.Now()
+	}
+	return &Iterator{client: c, ctx: ctx, logsQL: logsQL, params: p}
+}
+
+// QueryAll runs Query and collects every entry
+func (c *Client) QueryAll(ctx context.Context, logsQL string, opts ...Option) ([]Entry, error) {
+	it := c.Query(ctx, logsQL, opts...)
+	defer it.Close()
+	var entries []Entry
+	for it.Next() {
+		entries = append(entries, it.Entry())
+	}
+	return entries, it.Err()
+}
+
+// Iterator walks the results of Query. It is not safe for concurrent use.
+type Iterator struct {
+	client *Client
+	ctx    context.Context
+	logsQL string
+	params params
+
+	// offset is the number of entries in the pages already read; inPage and pageLimit count
+	// the entries read from and requested for the current page
+	offset    int
+	inPage    int
+	pageLimit int
+	returned  int
+
+	resp    *http.Response
+	cancel  context.CancelFunc
+	scanner *bufio.Scanner
+	entry   Entry
+	err     error
+	done    bool
+}
+
+// Next advances to the next entry, fetching the next page when needed. It returns false at
+// the end of the results or on error, see Err.
+func (it *Iterator) Next() bool {
+	for {
+		if it.done || it.err != nil {
+			return false
+		}
+		if it.params.limit > 0 && it.returned >= it.params.limit {
+			it.Close()
+			return false
+		}
+		if it.scanner == nil && !it.fetch() {
+			return false
+		}
+		if it.scanner.Scan() {
+			line := bytes.TrimSpace(it.scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var entry Entry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				it.fail(fmt.Errorf("decode entry: %w", err))
+				return false
+			}
+			it.entry = entry
+			it.inPage++
+			it.returned++
+			return true
+		}
+		if err := it.scanner.Err(); err != nil {
+			it.fail(fmt.Errorf("read response: %w", err))
+			return false
+		}
+		// A short page is the last one
+		full := it.inPage >= it.pageLimit
+		it.offset += it.inPage
+		it.closePage()
+		if !full {
+			it.done = true
+			return false
+		}
+	}
+}
+
+// Entry returns the current entry
+func (it *Iterator) Entry() Entry {
+	return it.entry
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Close releases the current response; it is safe to call more than once
+func (it *Iterator) Close() error {
+	it.done = true
+	it.closePage()
+	return nil
+}
+
+// fetch requests the page after the entries read so far
+func (it *Iterator) fetch() bool {
+	it.pageLimit = it.params.pageSize
+	if it.params.limit > 0 {
+		it.pageLimit = min(it.pageLimit, it.params.limit-it.returned)
+	}
+	form := it.params.form()
+	form.Set("query", it.logsQL+" | sort by (_time desc) | offset "+strconv.Itoa(it.offset)+" | limit "+strconv.Itoa(it.pageLimit))
+
+	ctx, cancel := it.client.requestContext(it.ctx)
+	resp, err := it.client.post(ctx, "/select/logsql/query", form)
+	if err != nil {
+		cancel()
+		it.err = err
+		return false
+	}
+	it.resp, it.cancel, it.inPage = resp, cancel, 0
+	it.scanner = bufio.NewScanner(resp.Body)
+	it.scanner.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+	return true
+}
+
+func (it *Iterator) fail(err error) {
+	it.err = err
+	it.closePage()
+}
+
+func (it *Iterator) closePage() {
+	if it.resp != nil {
+		_ = it.resp.Body.Close()
+		it.cancel()
+		it.resp, it.cancel, it.scanner = nil, nil, nil
+	}
+}
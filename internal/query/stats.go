@@ -0,0 +1,157 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// WithGroupBy splits Hits into one series per combination of values of fields
+func WithGroupBy(fields ...string) Option {
+	return func(p *params) { p.groupBy = fields }
+}
+
+// HitsSeries counts the logs matching a Hits query per time bucket, for one group of field values
+type HitsSeries struct {
+	// Fields holds the values of the WithGroupBy fields shared by the counted logs
+	Fields     map[string]string
+	Timestamps []time.Time
+	Values     []uint64
+	Total      uint64
+}
+
+// Hits counts the logs matching logsQL in buckets of step, using /select/logsql/hits. With
+// WithGroupBy the counts are split per field values; WithTimeRange bounds the buckets.
+func (c *Client) Hits(ctx context.Context, logsQL string, step time.Duration, opts ...Option) ([]HitsSeries, error) {
+	p := newParams(opts)
+	form := p.form()
+	form.Set("query", logsQL)
+	form.Set("step", formatDuration(step))
+	for _, field := range p.groupBy {
+		form.Add("field", field)
+	}
+
+	var resp struct {
+		Hits []struct {
+			Fields     map[string]string `json:"fields"`
+			Timestamps []time.Time       `json:"timestamps"`
+			Values     []uint64          `json:"values"`
+			Total      uint64            `json:"total"`
+		} `json:"hits"`
+	}
+	if err := c.getJSON(ctx, "/select/logsql/hits", form, &resp); err != nil {
+		return nil, err
+	}
+	series := make([]HitsSeries, 0, len(resp.Hits))
+	for _, h := range resp.Hits {
+		series = append(series, HitsSeries{Fields: h.Fields, Timestamps: h.Timestamps, Values: h.Values, Total: h.Total})
+	}
+	return series, nil
+}
+
+// StatsSample is one result row of StatsQuery: the stats pipe results and by-fields in Labels,
+// e.g. {"__name__": "count(*)", "level": "ERROR"}, and its value
+type StatsSample struct {
+	Labels map[string]string
+	Time   time.Time
+	Value  float64
+}
+
+// StatsPoint is one value of a StatsSeries
+type StatsPoint struct {
+	Time  time.Time
+	Value float64
+}
+
+// StatsSeries is one result of StatsQueryRange, see StatsSample for Labels
+type StatsSeries struct {
+	Labels map[string]string
+	Points []StatsPoint
+}
+
+// StatsQuery evaluates logsQL ending in a stats pipe, e.g. `* | stats by (level) count()`,
+// at time at (now when zero) with /select/logsql/stats_query
+func (c *Client) StatsQuery(ctx context.Context, logsQL string, at time.Time) ([]StatsSample, error) {
+	form := url.Values{"query": {logsQL}}
+	if !at.IsZero() {
+		form.Set("time", at.Format(time.RFC3339Nano))
+	}
+	var resp struct {
+		Data struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+				Value  statsValue        `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := c.getJSON(ctx, "/select/logsql/stats_query", form, &resp); err != nil {
+		return nil, err
+	}
+	samples := make([]StatsSample, 0, len(resp.Data.Result))
+	for _, r := range resp.Data.Result {
+		samples = append(samples, StatsSample{Labels: r.Metric, Time: r.Value.Time, Value: r.Value.Value})
+	}
+	return samples, nil
+}
+
+// StatsQueryRange evaluates logsQL ending in a stats pipe over buckets of step within
+// WithTimeRange, using /select/logsql/stats_query_range
+func (c *Client) StatsQueryRange(ctx context.Context, logsQL string, step time.Duration, opts ...Option) ([]StatsSeries, error) {
+	p := newParams(opts)
+	form := p.form()
+	form.Set("query", logsQL)
+	form.Set("step", formatDuration(step))
+	var resp struct {
+		Data struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+				Values []statsValue      `json:"values"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := c.getJSON(ctx, "/select/logsql/stats_query_range", form, &resp); err != nil {
+		return nil, err
+	}
+	series := make([]StatsSeries, 0, len(resp.Data.Result))
+	for _, r := range resp.Data.Result {
+		s := StatsSeries{Labels: r.Metric, Points: make([]StatsPoint, 0, len(r.Values))}
+		for _, v := range r.Values {
+			s.Points = append(s.Points, StatsPoint{Time: v.Time, Value: v.Value})
+		}
+		series = append(series, s)
+	}
+	return series, nil
+}
+
+// statsValue decodes the Prometheus-style [unix seconds, "value"] pair
+type statsValue struct {
+	Time  time.Time
+	Value float64
+}
+
+func (v *statsValue) UnmarshalJSON(data []byte) error {
+	var pair [2]interface{}
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	ts, ok := pair[0].(float64)
+	if !ok {
+		return fmt.Errorf("invalid stats timestamp %v", pair[0])
+	}
+	s, ok := pair[1].(string)
+	if !ok {
+		return fmt.Errorf("invalid stats value %v", pair[1])
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid stats value %q: %w", s, err)
+	}
+	sec, frac := math.Modf(ts)
+	v.Time = time.Unix(int64(sec), int64(frac*1e9)).UTC()
+	v.Value = value
+	return nil
+}
@@ -0,0 +1,81 @@
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// WithStartOffset makes Tail first return the logs of the last d before following new ones
+func WithStartOffset(d time.Duration) Option {
+	return func(p *params) { p.startOffset = d }
+}
+
+// Tail is a live stream of entries started by Client.Tail
+type Tail struct {
+	entries chan Entry
+	err     error
+}
+
+// Entries delivers matching logs as VictoriaLogs receives them. It is closed when the context
+// passed to Client.Tail ends or the stream fails, see Err.
+func (t *Tail) Entries() <-chan Entry {
+	return t.entries
+}
+
+// Err returns the error that ended the stream, nil when it ended with the context. It is only
+// valid once Entries is closed.
+func (t *Tail) Err() error {
+	return t.err
+}
+
+// Tail follows logsQL on /select/logsql/tail, like tail -f, until ctx ends. Only the
+// WithStartOffset option applies. Config.Timeout does not, as the stream stays open.
+//
+//	tail, err := client.Tail(ctx, `_stream:{service="demo-api"}`)
+//	for e := range tail.Entries() {
+//		fmt.Println(e.Time, e.Msg)
+//	}
+func (c *Client) Tail(ctx context.Context, logsQL string, opts ...Option) (*Tail, error) {
+	p := newParams(opts)
+	form := url.Values{"query": {logsQL}}
+	if p.startOffset > 0 {
+		form.Set("start_offset", formatDuration(p.startOffset))
+	}
+	resp, err := c.post(ctx, "/select/logsql/tail", form)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tail{entries: make(chan Entry)}
+	go func() {
+		defer close(t.entries)
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var entry Entry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				t.err = fmt.Errorf("decode entry: %w", err)
+				return
+			}
+			select {
+			case t.entries <- entry:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			t.err = fmt.Errorf("read stream: %w", err)
+		}
+	}()
+	return t, nil
+}
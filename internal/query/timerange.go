@@ -0,0 +1,120 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Range is a query time range, start <= _time < end; a zero bound is open. Build one with
+// Last, Today or ParseRange and pass it with WithRange.
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+// WithRange restricts the query to r, like WithTimeRange
+func WithRange(r Range) Option {
+	return WithTimeRange(r.From, r.To)
+}
+
+// Last is the range from d ago until now
+func Last(d time.Duration) Range {
+	now := time.Now()
+	return Range{From: now.Add(-d), To: now}
+}
+
+// Today is the range from local midnight until now
+func Today() Range {
+	now := time.Now()
+	return Range{From: midnight(now), To: now}
+}
+
+// ParseRange builds a range from two ParseTime expressions, e.g. ParseRange("now-15m", "now")
+// or ParseRange("yesterday", "today"); an empty expression leaves the bound open
+func ParseRange(from, to string) (Range, error) {
+	now := time.Now()
+	var r Range
+	var err error
+	if r.From, err = parseTime(from, now); err != nil {
+		return Range{}, err
+	}
+	if r.To, err = parseTime(to, now); err != nil {
+		return Range{}, err
+	}
+	if !r.From.IsZero() && !r.To.IsZero() && r.To.Before(r.From) {
+		return Range{}, fmt.Errorf("time range end %q is before its start %q", to, from)
+	}
+	return r, nil
+}
+
+// ParseTime parses an absolute or relative time:
+//
+//	now, now-15m, now-1h30m, now-7d, now-2w   relative to the current time
+//	today, yesterday, today-1d, today+9h      relative to local midnight
+//	2024-05-01, 2024-05-01T10:00:00Z          RFC3339 or a date in the local time zone
+//
+// An empty expression returns the zero time.
+func ParseTime(expr string) (time.Time, error) {
+	return parseTime(expr, time.Now())
+}
+
+func parseTime(expr string, now time.Time) (time.Time, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return time.Time{}, nil
+	}
+	today := midnight(now)
+	for _, anchor := range []struct {
+		name string
+		base time.Time
+	}{{"now", now}, {"today", today}, {"yesterday", today.AddDate(0, 0, -1)}} {
+		rest, ok := strings.CutPrefix(expr, anchor.name)
+		if !ok {
+			continue
+		}
+		if rest == "" {
+			return anchor.base, nil
+		}
+		if rest[0] != '-' && rest[0] != '+' {
+			continue
+		}
+		offset, err := parseOffset(rest[1:])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q: %w", expr, err)
+		}
+		if rest[0] == '-' {
+			offset = -offset
+		}
+		return anchor.base.Add(offset), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, expr); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, expr, now.Location()); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: want now-15m, today, yesterday, a date or RFC3339", expr)
+}
+
+// parseOffset parses a Go duration, or a whole number of days or weeks such as "7d" or "2w"
+func parseOffset(s string) (time.Duration, error) {
+	if unit := strings.TrimLeft(s, "0123456789"); unit == "d" || unit == "w" {
+		n, err := strconv.Atoi(strings.TrimSuffix(s, unit))
+		if err != nil {
+			return 0, err
+		}
+		day := 24 * time.Hour
+		if unit == "w" {
+			day *= 7
+		}
+		return time.Duration(n) * day, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("user already exists")
+)
+
+// UserRepository stores users
+type UserRepository interface {
+	Create(ctx context.Context, user User) error
+	Get(ctx context.Context, id string) (*User, error)
+	// List returns up to limit users starting at offset, in creation order, and the total count
+	List(ctx context.Context, offset, limit int) ([]User, int, error)
+	Update(ctx context.Context, user User) error
+	Delete(ctx context.Context, id string) error
+}
+
+// InMemoryUserRepository is a UserRepository backed by a map, safe for concurrent use
+type InMemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[string]User
+	order []string
+}
+
+func NewInMemoryUserRepository() *InMemoryUserRepository {
+	return &InMemoryUserRepository{
+		users: make(map[string]User),
+	}
+}
+
+func (r *InMemoryUserRepository) Create(ctx context.Context, user User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[user.ID]; ok {
+		return ErrUserExists
+	}
+	r.users[user.ID] = user
+	r.order = append(r.order, user.ID)
+	return nil
+}
+
+func (r *InMemoryUserRepository) Get(ctx context.Context, id string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (r *InMemoryUserRepository) List(ctx context.Context, offset, limit int) ([]User, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	total := len(r.order)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= total || limit <= 0 {
+		return []User{}, total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	users := make([]User, 0, end-offset)
+	for _, id := range r.order[offset:end] {
+		users = append(users, r.users[id])
+	}
+	return users, total, nil
+}
+
+func (r *InMemoryUserRepository) Update(ctx context.Context, user User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[user.ID]; !ok {
+		return ErrUserNotFound
+	}
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *InMemoryUserRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return ErrUserNotFound
+	}
+	delete(r.users, id)
+	for i, existing := range r.order {
+		if existing == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
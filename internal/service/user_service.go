@@ -16,11 +16,14 @@ type User struct {
 
 type UserService struct {
 	logger logger.Logger
+	repo   UserRepository
 }
 
-func NewUserService(logger logger.Logger) *UserService {
+// NewUserService creates a UserService. Mutations are additionally recorded with logger.Audit.
+func NewUserService(logger logger.Logger, repo UserRepository) *UserService {
 	return &UserService{
 		logger: logger,
+		repo:   repo,
 	}
 }
 
@@ -29,8 +32,8 @@ func (s *UserService) CreateUser(ctx context.Context, user User) error {
 
 	s.logger.Info(ctx, "Create new User", map[string]interface{}{
 		"user_id":  user.ID,
-		"username": user.Username,
-		"email":    user.Email,
+		"username": logger.Sanitize(user.Username),
+		"email":    logger.Sanitize(user.Email),
 		"action":   "create_user_start",
 	})
 	time.Sleep(100 * time.Millisecond)
@@ -38,19 +41,32 @@ func (s *UserService) CreateUser(ctx context.Context, user User) error {
 	if user.Username == "invalid" {
 		s.logger.Error(ctx, "Failed to create user", map[string]interface{}{
 			"user_id":  user.ID,
-			"username": user.Username,
+			"username": logger.Sanitize(user.Username),
 			"action":   "create_user_error",
 			"duration": time.Since(start).Milliseconds(),
 		})
 
 		return fmt.Errorf("failed to create user")
 	}
+	if err := s.repo.Create(ctx, user); err != nil {
+		s.logger.Error(ctx, "Failed to create user", map[string]interface{}{
+			"user_id":  user.ID,
+			"action":   "create_user_error",
+			"error":    err.Error(),
+			"duration": time.Since(start).Milliseconds(),
+		})
+		return err
+	}
 	s.logger.Info(ctx, "Create new User", map[string]interface{}{
 		"user_id":  user.ID,
-		"username": user.Username,
+		"username": logger.Sanitize(user.Username),
 		"action":   "create_user_success",
 		"duration": time.Since(start).Milliseconds(),
 	})
+	s.recordAudit(ctx, "user.created", map[string]interface{}{
+		"user_id":  user.ID,
+		"username": logger.Sanitize(user.Username),
+	})
 
 	return nil
 }
@@ -58,22 +74,122 @@ func (s *UserService) CreateUser(ctx context.Context, user User) error {
 func (s *UserService) GetUser(ctx context.Context, id string) (*User, error) {
 	start := time.Now()
 	s.logger.Debug(ctx, "Get User", map[string]interface{}{
-		"user_id": id,
+		"user_id": logger.Sanitize(id),
 		"action":  "get_user_start",
 	})
 	time.Sleep(100 * time.Millisecond)
 
-	user := &User{
-		ID:       id,
-		Username: "Demo User",
-		Email:    "demo@example.com",
+	user, err := s.repo.Get(ctx, id)
+	if err != nil {
+		s.logger.Warn(ctx, "Get User failed", map[string]interface{}{
+			"user_id":  logger.Sanitize(id),
+			"action":   "get_user_error",
+			"error":    err.Error(),
+			"duration": time.Since(start).Milliseconds(),
+		})
+		return nil, err
 	}
 
 	s.logger.Info(ctx, "Get User", map[string]interface{}{
 		"user_id":  user.ID,
-		"username": user.Username,
+		"username": logger.Sanitize(user.Username),
 		"action":   "get_user_success",
 		"duration": time.Since(start).Milliseconds(),
 	})
 	return user, nil
 }
+
+// ListUsers returns one page of users (pages start at 1) and the total number of users
+func (s *UserService) ListUsers(ctx context.Context, page, pageSize int) ([]User, int, error) {
+	start := time.Now()
+	users, total, err := s.repo.List(ctx, (page-1)*pageSize, pageSize)
+	if err != nil {
+		s.logger.Error(ctx, "List Users failed", map[string]interface{}{
+			"page":      page,
+			"page_size": pageSize,
+			"action":    "list_users_error",
+			"error":     err.Error(),
+			"duration":  time.Since(start).Milliseconds(),
+		})
+		return nil, 0, err
+	}
+
+	s.logger.Info(ctx, "List Users", map[string]interface{}{
+		"page":      page,
+		"page_size": pageSize,
+		"returned":  len(users),
+		"total":     total,
+		"action":    "list_users_success",
+		"duration":  time.Since(start).Milliseconds(),
+	})
+	return users, total, nil
+}
+
+func (s *UserService) UpdateUser(ctx context.Context, user User) error {
+	start := time.Now()
+	s.logger.Info(ctx, "Update User", map[string]interface{}{
+		"user_id":  logger.Sanitize(user.ID),
+		"username": logger.Sanitize(user.Username),
+		"email":    logger.Sanitize(user.Email),
+		"action":   "update_user_start",
+	})
+
+	if err := s.repo.Update(ctx, user); err != nil {
+		s.logger.Warn(ctx, "Update User failed", map[string]interface{}{
+			"user_id":  logger.Sanitize(user.ID),
+			"action":   "update_user_error",
+			"error":    err.Error(),
+			"duration": time.Since(start).Milliseconds(),
+		})
+		return err
+	}
+
+	s.logger.Info(ctx, "Update User", map[string]interface{}{
+		"user_id":  user.ID,
+		"action":   "update_user_success",
+		"duration": time.Since(start).Milliseconds(),
+	})
+	s.recordAudit(ctx, "user.updated", map[string]interface{}{
+		"user_id":  user.ID,
+		"username": logger.Sanitize(user.Username),
+	})
+	return nil
+}
+
+func (s *UserService) DeleteUser(ctx context.Context, id string) error {
+	start := time.Now()
+	s.logger.Info(ctx, "Delete User", map[string]interface{}{
+		"user_id": logger.Sanitize(id),
+		"action":  "delete_user_start",
+	})
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		s.logger.Warn(ctx, "Delete User failed", map[string]interface{}{
+			"user_id":  logger.Sanitize(id),
+			"action":   "delete_user_error",
+			"error":    err.Error(),
+			"duration": time.Since(start).Milliseconds(),
+		})
+		return err
+	}
+
+	s.logger.Info(ctx, "Delete User", map[string]interface{}{
+		"user_id":  id,
+		"action":   "delete_user_success",
+		"duration": time.Since(start).Milliseconds(),
+	})
+	s.recordAudit(ctx, "user.deleted", map[string]interface{}{
+		"user_id": id,
+	})
+	return nil
+}
+
+// recordAudit writes a mutation event to the audit stream
+func (s *UserService) recordAudit(ctx context.Context, event string, fields map[string]interface{}) {
+	if err := s.logger.Audit(ctx, event, fields); err != nil {
+		s.logger.Error(ctx, "Failed to write audit log", map[string]interface{}{
+			"event": event,
+			"error": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,32}$`)
+
+// ValidationError lists the invalid fields of a request and why they were rejected
+type ValidationError struct {
+	Fields map[string]string `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s: %s", name, e.Fields[name]))
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Validate checks username and email, returning a *ValidationError if any is invalid
+func (u User) Validate() error {
+	fields := make(map[string]string)
+
+	switch {
+	case u.Username == "":
+		fields["username"] = "is required"
+	case !usernamePattern.MatchString(u.Username):
+		fields["username"] = "must be 3-32 characters of letters, digits, '.', '_' or '-'"
+	}
+
+	switch {
+	case u.Email == "":
+		fields["email"] = "is required"
+	default:
+		addr, err := mail.ParseAddress(u.Email)
+		if err != nil || addr.Address != u.Email {
+			fields["email"] = "must be a valid email address"
+		}
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}